@@ -0,0 +1,272 @@
+package pf
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Parameter locations supported by the binding struct tags.
+const (
+	inPath   = "path"
+	inQuery  = "query"
+	inHeader = "header"
+	inCookie = "cookie"
+)
+
+var paramTags = []string{inPath, inQuery, inHeader, inCookie}
+
+// BindError is returned when a path, query, header or cookie parameter cannot
+// be converted to the type of the field it is bound to. It is reported to the
+// client as 400 Bad Request.
+type BindError struct {
+	In    string
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("invalid %s parameter %q: %v", e.In, e.Name, e.Err)
+}
+
+func (e *BindError) Unwrap() []error {
+	return []error{ErrBadRequest, e.Err}
+}
+
+// paramField is a request struct field bound to a path, query, header or
+// cookie parameter.
+type paramField struct {
+	field reflect.StructField
+	index []int
+	in    string
+	name  string
+	typ   reflect.Type
+}
+
+// binding describes how a request type is populated from an HTTP request.
+type binding struct {
+	params []paramField
+
+	// body reports whether the type has any fields decoded from the request
+	// body.
+	body bool
+}
+
+var bindings sync.Map // map[reflect.Type]*binding
+
+// getBinding returns the binding of typ. Fields tagged with `path:"..."`,
+// `query:"..."`, `header:"..."` or `cookie:"..."` are bound to parameters;
+// any other exported field is decoded from the body. Non-struct types are
+// always decoded from the body.
+func getBinding(typ reflect.Type) *binding {
+	if b, ok := bindings.Load(typ); ok {
+		return b.(*binding)
+	}
+
+	b := new(binding)
+	if typ.Kind() == reflect.Struct {
+		b.collect(typ, nil)
+	} else {
+		b.body = true
+	}
+
+	actual, _ := bindings.LoadOrStore(typ, b)
+	return actual.(*binding)
+}
+
+func (b *binding) collect(typ reflect.Type, index []int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		if in, name, ok := paramTag(field); ok {
+			b.params = append(b.params, paramField{
+				field: field,
+				index: fieldIndex,
+				in:    in,
+				name:  name,
+				typ:   field.Type,
+			})
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			b.collect(field.Type, fieldIndex)
+			continue
+		}
+
+		if field.IsExported() && field.Tag.Get("json") != "-" {
+			b.body = true
+		}
+	}
+}
+
+// paramTag returns the parameter location and name of a field tagged with one
+// of the binding tags.
+func paramTag(field reflect.StructField) (in, name string, ok bool) {
+	for _, tag := range paramTags {
+		if name, ok := field.Tag.Lookup(tag); ok && name != "" {
+			return tag, name, true
+		}
+	}
+	return "", "", false
+}
+
+// bind populates the parameter fields of v from r. They are reset first, as
+// they are never decoded from the body: parameters absent from r are left
+// zero.
+func (b *binding) bind(r *http.Request, v reflect.Value) error {
+	var query map[string][]string
+	for _, param := range b.params {
+		var values []string
+		switch param.in {
+		case inPath:
+			if value := chi.URLParam(r, param.name); value != "" {
+				values = []string{value}
+			}
+		case inQuery:
+			if query == nil {
+				query = r.URL.Query()
+			}
+			values = query[param.name]
+		case inHeader:
+			values = r.Header.Values(param.name)
+			if isSliceParam(param.typ) {
+				values = splitList(values)
+			}
+		case inCookie:
+			if c, err := r.Cookie(param.name); err == nil {
+				values = []string{c.Value}
+			}
+		}
+
+		field := v.FieldByIndex(param.index)
+		field.SetZero()
+		if len(values) == 0 {
+			continue
+		}
+
+		err := setValue(field, values)
+		if err != nil {
+			return &BindError{
+				In:    param.in,
+				Name:  param.name,
+				Value: values[0],
+				Err:   err,
+			}
+		}
+	}
+	return nil
+}
+
+// isSliceParam reports whether a parameter of type typ holds multiple values.
+func isSliceParam(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Slice && !reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
+
+// splitList splits comma-separated header values.
+func splitList(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// setValue converts values into v. Slices are populated from every value,
+// other types from the first one.
+func setValue(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), values)
+	}
+
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+
+	if v.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			err := setValue(slice.Index(i), []string{value})
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	return setString(v, values[0])
+}
+
+func setString(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
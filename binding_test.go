@@ -0,0 +1,85 @@
+package pf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type BindRequest struct {
+	ID     int           `path:"id"`
+	Limit  *uint         `query:"limit"`
+	Tags   []string      `query:"tag"`
+	Since  time.Time     `query:"since"`
+	Wait   time.Duration `query:"wait"`
+	Tenant string        `header:"X-Tenant"`
+	Flags  []bool        `header:"X-Flags"`
+}
+
+type BindOrder struct {
+	ID       int    `path:"id"`
+	Limit    *uint  `query:"limit"`
+	Tenant   string `header:"X-Tenant"`
+	Quantity int
+}
+
+func TestBinding(t *testing.T) {
+	var got BindRequest
+	var gotOrder BindOrder
+
+	r := NewRouter()
+	Get(r, "/items/{id}", func(w ResponseWriter[struct{}], r *Request[BindRequest]) error {
+		got = r.Body
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/42?limit=10&tag=a&tag=b&since=2024-01-02T03:04:05Z&wait=1m", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Flags", "true, false")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if got.ID != 42 || got.Limit == nil || *got.Limit != 10 || got.Tenant != "acme" || got.Wait != time.Minute {
+		t.Errorf("unexpected binding: %+v", got)
+	}
+	if len(got.Tags) != 2 || got.Tags[1] != "b" || len(got.Flags) != 2 || got.Flags[1] {
+		t.Errorf("unexpected slices: %+v", got)
+	}
+	if !got.Since.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("since = %v", got.Since)
+	}
+
+	// Parameters are not decoded from the body
+	Post(r, "/items/{id}", func(w ResponseWriter[struct{}], r *Request[BindOrder]) error {
+		gotOrder = r.Body
+		return nil
+	})
+	req = httptest.NewRequest(http.MethodPost, "/items/42", strings.NewReader(`{"ID":7,"Limit":10,"Tenant":"evil","Quantity":2}`))
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || gotOrder.ID != 42 || gotOrder.Limit != nil || gotOrder.Tenant != "" || gotOrder.Quantity != 2 {
+		t.Errorf("status = %d, body = %+v", rec.Code, gotOrder)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	op := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/items/{id}"].Get
+	if len(op.Parameters) != 7 {
+		t.Fatalf("len(parameters) = %d, want 7", len(op.Parameters))
+	}
+	if p := op.Parameters[0]; p.In != "path" || !p.Required || p.Type != "integer" {
+		t.Errorf("unexpected path parameter: %+v", p.ParamProps)
+	}
+	if p := op.Parameters[2]; p.Type != "array" || p.CollectionFormat != "multi" {
+		t.Errorf("unexpected query parameter: %+v", p)
+	}
+}
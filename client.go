@@ -0,0 +1,114 @@
+package pf
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DecodeError decodes an error response written by HandleError or
+// HandleProblem into a *Problem, *ValidationError or *Error, which match the
+// errors of the package for the response status with errors.Is. It is used
+// by generated clients.
+func DecodeError(res *http.Response) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/problem+json":
+		p := new(Problem)
+		if err := json.Unmarshal(data, p); err == nil {
+			if p.Status == 0 {
+				p.Status = res.StatusCode
+			}
+			return p
+		}
+
+	case "application/json":
+		var body struct {
+			errorBody
+			Errors []FieldError `json:"errors"`
+		}
+		if err := json.Unmarshal(data, &body); err == nil {
+			if res.StatusCode == http.StatusUnprocessableEntity && body.Errors != nil {
+				return &ValidationError{Fields: body.Errors}
+			}
+
+			e := &Error{
+				Status:  res.StatusCode,
+				Code:    body.Code,
+				Message: body.Message,
+				Details: body.Details,
+			}
+			if body.Cause != "" {
+				e.Err = errors.New(body.Cause)
+				e.Exposed = true
+			}
+			return e
+		}
+	}
+
+	return &Error{Status: res.StatusCode, Message: strings.TrimSpace(string(data))}
+}
+
+// FormatParam formats v as the value of a path, query, header or cookie
+// parameter, the way pf binds it to request fields.
+func FormatParam(v any) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+
+	if d, ok := rv.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	if m, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	if rv.CanAddr() {
+		if m, ok := rv.Addr().Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+	}
+
+	return fmt.Sprint(rv.Interface())
+}
+
+// FormatParams formats the items of the slice v, or v itself, as parameter
+// values with FormatParam. Nil and zero values are left out.
+func FormatParams(v any) []string {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.IsZero() {
+		return nil
+	}
+
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		out := make([]string, rv.Len())
+		for i := range out {
+			out[i] = FormatParam(rv.Index(i).Interface())
+		}
+		return out
+	}
+
+	return []string{FormatParam(v)}
+}
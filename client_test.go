@@ -0,0 +1,146 @@
+package pf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func clientRouter() *Router {
+	r := NewRouter()
+	Route(r, "/wat", func(r *Router) {
+		Post(r, "/uploadbeer", func(w ResponseWriter[struct{}], r *Request[TestRequest]) error {
+			return nil
+		}, WithSummary("Uploads beer"))
+	})
+	Get(r, "/items/{id}", func(w ResponseWriter[[]ValidatedItem], r *Request[BindRequest]) error {
+		return nil
+	})
+	Get(r, "/files/{type}/*", func(w ResponseWriter[[]byte], r *Request[struct{}]) error {
+		return nil
+	})
+	return r
+}
+
+func TestGenerateClient(t *testing.T) {
+	src, err := GenerateClient(clientRouter(), "beer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(src)
+
+	for _, want := range []string{
+		"package beer",
+		"// PostWatUploadbeer uploads beer.\nfunc (c *Client) PostWatUploadbeer(ctx context.Context, req TestRequest) (struct{}, error) {",
+		"func (c *Client) GetItemsId(ctx context.Context, req BindRequest) ([]ValidatedItem, error) {",
+		`path = strings.Replace(path, "{id}", url.PathEscape(pf.FormatParam(req.ID)), 1)`,
+		`query.Add("tag", v)`,
+		`header.Add("X-Tenant", v)`,
+		"err := c.do(ctx, \"GET\", path, query, header, nil, &res)",
+		"func (c *Client) GetFilesType(ctx context.Context, typeParam string, rest string) ([]uint8, error) {",
+		"\tSince  time.Time     `query:\"since\" json:\"-\"`\n",
+		"type ValidatedItem struct {\n\tName string `json:\"name\" validate:\"required,regex=^[a-z]+$\"`\n}",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("missing %q in:\n%s", want, code)
+		}
+	}
+}
+
+func TestGeneratedClientBuilds(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	src, err := GenerateClient(clientRouter(), "beer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Build the client in a module requiring this one from the working tree
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	gomod := fmt.Sprintf("module example.com/beer\n\ngo 1.23\n\nrequire github.com/TaeKwonZeus/pf v0.0.0\n\nreplace github.com/TaeKwonZeus/pf => %s\n", root)
+	for name, data := range map[string][]byte{"go.mod": []byte(gomod), "go.sum": sum, "client.go": src} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}} {
+		cmd := exec.Command(goTool, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		handler func(w http.ResponseWriter, r *http.Request, err error)
+		err     error
+		target  error
+	}{
+		{func(w http.ResponseWriter, r *http.Request, err error) { HandleError(w, err) }, ErrNotFound, ErrNotFound},
+		{func(w http.ResponseWriter, r *http.Request, err error) { HandleError(w, err) }, NewError(409, "taken", "Name taken"), ErrConflict},
+		{func(w http.ResponseWriter, r *http.Request, err error) { HandleError(w, err) }, &ValidationError{Fields: []FieldError{{Field: "name"}}}, ErrUnprocessableEntity},
+		{HandleProblem, NewProblem(403, "Nope").With("reason", "banned"), ErrForbidden},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		test.handler(rec, httptest.NewRequest(http.MethodGet, "/", nil), test.err)
+
+		err := DecodeError(rec.Result())
+		if !errors.Is(err, test.target) {
+			t.Errorf("%v: decoded %#v, want %v", test.err, err, test.target)
+		}
+
+		var appErr *Error
+		if errors.As(test.err, &appErr) && (!errors.As(err, &appErr) || appErr.Code != "taken") {
+			t.Errorf("decoded %#v", err)
+		}
+		var p *Problem
+		if errors.As(test.err, &p) && (!errors.As(err, &p) || p.Extensions["reason"] != "banned") {
+			t.Errorf("decoded %#v", err)
+		}
+	}
+}
+
+func TestFormatParams(t *testing.T) {
+	limit := uint(0)
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		v    any
+		want []string
+	}{
+		{[]string{"a", "b"}, []string{"a", "b"}},
+		{&limit, []string{"0"}},
+		{(*uint)(nil), nil},
+		{0, nil},
+		{date, []string{"2024-01-02T03:04:05Z"}},
+		{time.Minute, []string{"1m0s"}},
+	}
+	for _, test := range tests {
+		if got := FormatParams(test.v); !slices.Equal(got, test.want) {
+			t.Errorf("FormatParams(%v) = %q, want %q", test.v, got, test.want)
+		}
+	}
+}
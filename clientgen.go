@@ -0,0 +1,577 @@
+package pf
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/format"
+	"go/token"
+	"mime/multipart"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-openapi/spec"
+)
+
+const pfPath = "github.com/TaeKwonZeus/pf"
+
+// GenerateClient generates the source of a Go package called pkg with a
+// Client calling the handlers of r. Client has a method per operation, named
+// after its method and path, such as GetUsersId for GET /users/{id}, taking
+// the URL parameters not bound to request fields and the request.
+//
+// The request and response types are declared in the package, except types
+// of the standard library and types encoding themselves, which are imported.
+// Errors are decoded with DecodeError.
+func GenerateClient(r *Router, pkg string) ([]byte, error) {
+	g := clientGen{
+		imports:  make(map[string]string),
+		declared: make(map[reflect.Type]bool),
+		std:      make(map[string]bool),
+	}
+	for _, path := range []string{"bytes", "context", "encoding/json", "io", "net/http", "net/url", "strings", pfPath} {
+		g.importName(path)
+	}
+
+	ops := g.operations(r)
+	for _, op := range ops {
+		if _, seq := seqItem(op.sig.resType); seq || isStreamBody(op.sig.reqType) {
+			continue
+		}
+		g.collect(op.sig.reqType)
+		if op.sig.resType != fileType && op.sig.resType != readerType {
+			g.collect(op.sig.resType)
+		}
+	}
+	if err := g.name(r.namer()); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	body.WriteString(clientRuntime)
+	methods := make(map[string]string)
+	for _, op := range ops {
+		if prev, ok := methods[op.name]; ok {
+			return nil, fmt.Errorf("pf: operations %s and %s have the same client method name %s", prev, op.method+" "+op.path, op.name)
+		}
+		methods[op.name] = op.method + " " + op.path
+		g.writeMethod(&body, op)
+	}
+	g.writeTypes(&body)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by pf. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	var std, other []string
+	for p := range g.imports {
+		if g.isStd(p) {
+			std = append(std, p)
+		} else {
+			other = append(other, p)
+		}
+	}
+	slices.Sort(std)
+	slices.Sort(other)
+	for i, paths := range [][]string{std, other} {
+		if i > 0 && len(paths) > 0 {
+			src.WriteString("\n")
+		}
+		for _, p := range paths {
+			if name := g.imports[p]; name != path.Base(p) {
+				fmt.Fprintf(&src, "\t%s %q\n", name, p)
+			} else {
+				fmt.Fprintf(&src, "\t%q\n", p)
+			}
+		}
+	}
+	src.WriteString(")\n")
+	src.Write(body.Bytes())
+
+	return format.Source(src.Bytes())
+}
+
+// clientRuntime is the part of generated clients independent of the router.
+const clientRuntime = `
+// Client calls the API at BaseURL with HTTPClient.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client calling the API at baseURL with
+// http.DefaultClient.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: http.DefaultClient}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body any, out any) error {
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(body)
+		header.Set("Content-Type", "application/octet-stream")
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+
+	target := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return pf.DecodeError(res)
+	}
+
+	switch out := out.(type) {
+	case *struct{}:
+		return nil
+	case *[]byte:
+		*out, err = io.ReadAll(res.Body)
+		return err
+	case *string:
+		data, err := io.ReadAll(res.Body)
+		*out = string(data)
+		return err
+	default:
+		err := json.NewDecoder(res.Body).Decode(out)
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+}
+`
+
+type clientGen struct {
+	// imports maps imported paths to package names.
+	imports map[string]string
+
+	// declared holds the types declared in the client, named by names.
+	declared map[reflect.Type]bool
+	order    []reflect.Type
+	names    map[reflect.Type]string
+
+	// std caches whether packages are part of the standard library.
+	std map[string]bool
+}
+
+type clientOp struct {
+	sig    *handlerSignature
+	method string
+	path   string
+	params []pathParam
+	name   string
+	doc    string
+}
+
+// operations returns the operations of r sorted by path and method.
+func (g *clientGen) operations(r *Router) []clientOp {
+	var ops []clientOp
+	for pattern, methods := range r.traverseSignatures() {
+		p, params := parsePattern(pattern)
+		for method, sig := range methods {
+			op := clientOp{sig: sig, method: method, path: p, params: params}
+			op.name = goIdent(strings.ToLower(method) + " " + p)
+
+			var o spec.Operation
+			applyProps(&o, slices.Concat(sig.router.groupProps(), sig.props))
+			op.doc = o.Summary
+			ops = append(ops, op)
+		}
+	}
+
+	slices.SortFunc(ops, func(a, b clientOp) int {
+		return strings.Compare(a.path+" "+a.method, b.path+" "+b.method)
+	})
+	return ops
+}
+
+// goIdent converts s to an exported Go identifier, so "get /users/{id}"
+// becomes GetUsersId.
+func goIdent(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// importName imports the package at path, returning its name.
+func (g *clientGen) importName(p string) string {
+	if name, ok := g.imports[p]; ok {
+		return name
+	}
+
+	name := path.Base(p)
+	if v := strings.TrimPrefix(name, "v"); v != name && strings.Trim(v, "0123456789") == "" {
+		name = path.Base(path.Dir(p))
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+	for taken := true; taken; {
+		taken = false
+		for _, other := range g.imports {
+			if other == name {
+				name += "_"
+				taken = true
+			}
+		}
+	}
+
+	g.imports[p] = name
+	return name
+}
+
+// isStd reports whether the package at path is part of the standard library.
+func (g *clientGen) isStd(p string) bool {
+	std, ok := g.std[p]
+	if !ok {
+		pkg, err := build.Import(p, "", build.FindOnly)
+		std = err == nil && pkg.Goroot
+		g.std[p] = std
+	}
+	return std
+}
+
+// encodesItself reports whether typ has a custom JSON encoding.
+func encodesItself(typ reflect.Type) bool {
+	ptr := reflect.PointerTo(typ)
+	if ptr.Implements(jsonMarshalerType) || ptr.Implements(textMarshalerType) {
+		return true
+	}
+	knownSchemasMu.RLock()
+	defer knownSchemasMu.RUnlock()
+	_, ok := knownSchemas[typ]
+	return ok
+}
+
+// declare reports whether typ is declared in the client rather than
+// imported.
+func (g *clientGen) declare(typ reflect.Type) bool {
+	if typ.Name() == "" || typ.PkgPath() == "" {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	}
+	return !encodesItself(typ) && !g.isStd(typ.PkgPath())
+}
+
+// collect finds the types to declare used by typ.
+func (g *clientGen) collect(typ reflect.Type) {
+	if typ == nil || g.declared[typ] {
+		return
+	}
+	if g.declare(typ) {
+		g.declared[typ] = true
+		g.order = append(g.order, typ)
+	} else if typ.Name() != "" {
+		// Imported types come with their own fields
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		g.collect(typ.Elem())
+	case reflect.Map:
+		g.collect(typ.Key())
+		g.collect(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); field.IsExported() || field.Anonymous {
+				g.collect(field.Type)
+			}
+		}
+	}
+}
+
+// name names the declared types like the definitions of the spec.
+func (g *clientGen) name(namer func(reflect.Type) string) error {
+	m := newStructMap(nil)
+	for _, typ := range g.order {
+		m.schemas[typ] = spec.Schema{}
+	}
+	names, err := m.name(namer)
+	if err != nil {
+		return err
+	}
+
+	g.names = make(map[reflect.Type]string, len(names))
+	taken := make(map[string]reflect.Type)
+	for _, typ := range g.order {
+		name := goIdent(names[typ])
+		if other, ok := taken[name]; ok {
+			return fmt.Errorf("pf: types %v and %v have the same client type name %s", other, typ, name)
+		}
+		taken[name] = typ
+		g.names[typ] = name
+	}
+
+	slices.SortFunc(g.order, func(a, b reflect.Type) int {
+		return strings.Compare(g.names[a], g.names[b])
+	})
+	return nil
+}
+
+// expr returns the Go expression of typ in the client.
+func (g *clientGen) expr(typ reflect.Type) string {
+	if g.declared[typ] {
+		return g.names[typ]
+	}
+
+	if typ.Name() != "" {
+		switch {
+		case typ.PkgPath() == "":
+			return typ.Name()
+		case strings.Contains(typ.Name(), "["), typ.PkgPath() == "main":
+			// Instantiations and types of main can't be referred to
+			return g.importName("encoding/json") + ".RawMessage"
+		default:
+			return g.importName(typ.PkgPath()) + "." + typ.Name()
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return "*" + g.expr(typ.Elem())
+	case reflect.Slice:
+		return "[]" + g.expr(typ.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(typ.Len()) + "]" + g.expr(typ.Elem())
+	case reflect.Map:
+		return "map[" + g.expr(typ.Key()) + "]" + g.expr(typ.Elem())
+	case reflect.Struct:
+		var b strings.Builder
+		g.writeFields(&b, typ)
+		if b.Len() == 0 {
+			return "struct{}"
+		}
+		return "struct {\n" + b.String() + "}"
+	default:
+		return "any"
+	}
+}
+
+// writeFields writes the exported and embedded fields of typ. Fields bound
+// to parameters are left out of the JSON body.
+func (g *clientGen) writeFields(b *strings.Builder, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag
+		if _, _, ok := paramTag(field); ok {
+			if _, ok := tag.Lookup("json"); !ok {
+				tag = reflect.StructTag(strings.TrimSpace(string(tag) + ` json:"-"`))
+			}
+		}
+
+		if field.Anonymous {
+			b.WriteString("\t" + g.expr(field.Type))
+		} else {
+			b.WriteString("\t" + field.Name + " " + g.expr(field.Type))
+		}
+		if strings.Contains(string(tag), "`") {
+			b.WriteString(" " + strconv.Quote(string(tag)))
+		} else if tag != "" {
+			b.WriteString(" `" + string(tag) + "`")
+		}
+		b.WriteString("\n")
+	}
+}
+
+func (g *clientGen) writeTypes(b *bytes.Buffer) {
+	for _, typ := range g.order {
+		var def string
+		if typ.Kind() == reflect.Struct {
+			var fields strings.Builder
+			g.writeFields(&fields, typ)
+			def = "struct {\n" + fields.String() + "}"
+		} else {
+			// Declare the underlying type
+			def = g.expr(underlying(typ))
+		}
+		fmt.Fprintf(b, "\ntype %s %s\n", g.names[typ], def)
+	}
+}
+
+// underlying returns the unnamed type underlying the named non-struct typ.
+func underlying(typ reflect.Type) reflect.Type {
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return reflect.PointerTo(typ.Elem())
+	case reflect.Slice:
+		return reflect.SliceOf(typ.Elem())
+	case reflect.Array:
+		return reflect.ArrayOf(typ.Len(), typ.Elem())
+	case reflect.Map:
+		return reflect.MapOf(typ.Key(), typ.Elem())
+	case reflect.Bool:
+		return reflect.TypeFor[bool]()
+	case reflect.Int:
+		return reflect.TypeFor[int]()
+	case reflect.Int8:
+		return reflect.TypeFor[int8]()
+	case reflect.Int16:
+		return reflect.TypeFor[int16]()
+	case reflect.Int32:
+		return reflect.TypeFor[int32]()
+	case reflect.Int64:
+		return reflect.TypeFor[int64]()
+	case reflect.Uint:
+		return reflect.TypeFor[uint]()
+	case reflect.Uint8:
+		return reflect.TypeFor[uint8]()
+	case reflect.Uint16:
+		return reflect.TypeFor[uint16]()
+	case reflect.Uint32:
+		return reflect.TypeFor[uint32]()
+	case reflect.Uint64:
+		return reflect.TypeFor[uint64]()
+	case reflect.Uintptr:
+		return reflect.TypeFor[uintptr]()
+	case reflect.Float32:
+		return reflect.TypeFor[float32]()
+	case reflect.Float64:
+		return reflect.TypeFor[float64]()
+	case reflect.String:
+		return reflect.TypeFor[string]()
+	default:
+		return reflect.TypeFor[any]()
+	}
+}
+
+// reservedArgs are the names used in generated methods.
+var reservedArgs = []string{"c", "ctx", "req", "path", "query", "header", "res", "err", "url", "http", "strings", "pf"}
+
+func (g *clientGen) writeMethod(b *bytes.Buffer, op clientOp) {
+	reqType, resType := op.sig.reqType, op.sig.resType
+	if reqType == reflect.TypeFor[*multipart.Form]() {
+		fmt.Fprintf(b, "\n// %s is not generated: multipart requests are not supported.\n", op.name)
+		return
+	}
+	if _, seq := seqItem(resType); seq || op.sig.events || op.sig.webSocket || isStreamBody(reqType) {
+		fmt.Fprintf(b, "\n// %s is not generated: streaming endpoints are not supported.\n", op.name)
+		return
+	}
+	switch resType {
+	case nil:
+		resType = reflect.TypeFor[struct{}]()
+	case fileType, readerType:
+		resType = reflect.TypeFor[[]byte]()
+	}
+
+	var binding *binding
+	hasReq := reqType != nil && reqType != reflect.TypeFor[struct{}]()
+	if hasReq {
+		binding = getBinding(reqType)
+	}
+
+	// URL parameters not bound to request fields are arguments
+	args := []string{"ctx context.Context"}
+	bound := make(map[string]string)
+	if binding != nil {
+		for _, p := range binding.params {
+			if p.in == inPath {
+				bound[p.name] = p.field.Name
+			}
+		}
+	}
+	var pathArgs []string
+	for _, p := range op.params {
+		if _, ok := bound[p.name]; !ok {
+			arg := goIdent(p.name)
+			if p.name == wildcardParam {
+				arg = "Rest"
+			}
+			arg = strings.ToLower(arg[:1]) + arg[1:]
+			if token.IsKeyword(arg) || slices.Contains(reservedArgs, arg) {
+				arg += "Param"
+			}
+			args = append(args, arg+" string")
+			pathArgs = append(pathArgs, p.name, arg)
+		}
+	}
+	if hasReq {
+		args = append(args, "req "+g.expr(reqType))
+	}
+	res := g.expr(resType)
+
+	doc := "calls " + op.method + " " + op.path
+	if op.doc != "" {
+		doc = strings.ToLower(op.doc[:1]) + op.doc[1:]
+	}
+	fmt.Fprintf(b, "\n// %s %s.\n", op.name, strings.TrimSuffix(doc, "."))
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", op.name, strings.Join(args, ", "), res)
+	fmt.Fprintf(b, "\tpath := %q\n", op.path)
+	for i := 0; i < len(pathArgs); i += 2 {
+		value := "url.PathEscape(" + pathArgs[i+1] + ")"
+		if pathArgs[i] == wildcardParam {
+			value = pathArgs[i+1]
+		}
+		fmt.Fprintf(b, "\tpath = strings.Replace(path, %q, %s, 1)\n", "{"+pathArgs[i]+"}", value)
+	}
+	b.WriteString("\tquery := url.Values{}\n\theader := http.Header{}\n")
+
+	body := "nil"
+	if binding != nil {
+		for _, p := range binding.params {
+			switch p.in {
+			case inPath:
+				fmt.Fprintf(b, "\tpath = strings.Replace(path, %q, url.PathEscape(pf.FormatParam(req.%s)), 1)\n", "{"+p.name+"}", p.field.Name)
+			case inQuery:
+				fmt.Fprintf(b, "\tfor _, v := range pf.FormatParams(req.%s) {\n\t\tquery.Add(%q, v)\n\t}\n", p.field.Name, p.name)
+			case inHeader:
+				fmt.Fprintf(b, "\tfor _, v := range pf.FormatParams(req.%s) {\n\t\theader.Add(%q, v)\n\t}\n", p.field.Name, p.name)
+			case inCookie:
+				fmt.Fprintf(b, "\tif v := pf.FormatParam(req.%s); v != \"\" {\n\t\theader.Add(\"Cookie\", (&http.Cookie{Name: %q, Value: v}).String())\n\t}\n", p.field.Name, p.name)
+			}
+		}
+		if binding.body {
+			body = "req"
+		}
+	}
+
+	fmt.Fprintf(b, "\tvar res %s\n", res)
+	fmt.Fprintf(b, "\terr := c.do(ctx, %q, path, query, header, %s, &res)\n", op.method, body)
+	b.WriteString("\treturn res, err\n}\n")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+func runClient(args []string) error {
+	flags := flag.NewFlagSet("pf client", flag.ExitOnError)
+	pkg := flags.String("pkg", ".", "package of the router constructor")
+	fn := flags.String("func", "NewRouter", "router constructor, of type func() *pf.Router")
+	name := flags.String("package", "", "client package name (default the name of the output directory, or client)")
+	out := flags.String("o", "", "output file (default stdout)")
+	flags.Parse(args)
+
+	if *name == "" {
+		*name = "client"
+		if *out != "" {
+			abs, err := filepath.Abs(*out)
+			if err != nil {
+				return err
+			}
+			*name = filepath.Base(filepath.Dir(abs))
+		}
+	}
+
+	generate := fmt.Sprintf("data, err := pf.GenerateClient(r, %q)\n\tvar warnings []string", *name)
+	data, err := runProgram(*pkg, *fn, generate, false)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, data)
+}
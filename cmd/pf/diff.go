@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TaeKwonZeus/pf"
+)
+
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("pf diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "write the report as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return errors.New("usage: pf diff [-json] base head")
+	}
+
+	base, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	head, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diff, err := pf.DiffSpecs(base, head)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Breaking bool `json:"breaking"`
+			pf.SpecDiff
+		}{diff.Breaking(), diff}); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range diff.Changes {
+			fmt.Println(c)
+		}
+	}
+
+	if diff.Breaking() {
+		return errors.New("breaking changes found")
+	}
+	return nil
+}
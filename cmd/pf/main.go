@@ -0,0 +1,71 @@
+// Command pf works with the specs of pf routers at build time.
+//
+// Usage:
+//
+//	pf spec [flags]
+//	pf client [flags]
+//	pf ts [flags]
+//	pf diff [-json] base head
+//
+// The spec command writes the spec of the router returned by a constructor
+// of type func() *pf.Router, such as:
+//
+//	//go:generate go run github.com/TaeKwonZeus/pf/cmd/pf spec -func NewRouter -format openapi.yaml -o openapi.yaml
+//
+// The constructor may be in a main package, whose init functions run before
+// it, but not its main function. Nothing is written to the module.
+//
+// The client command writes a Go client of the router, generated with
+// pf.GenerateClient.
+//
+// The ts command writes TypeScript interfaces and a fetch-based client of the
+// router, generated with pf.GenerateTypeScript.
+//
+// The diff command compares two specs written by the spec command, Swagger
+// 2.0 or OpenAPI 3.1, and exits with status 1 if head has breaking changes,
+// to be used as a CI gate.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "spec":
+		err = runSpec(os.Args[2:])
+	case "client":
+		err = runClient(os.Args[2:])
+	case "ts":
+		err = runTypeScript(os.Args[2:])
+	case "diff":
+		err = runDiff(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pf:", err)
+		os.Exit(1)
+	}
+}
+
+// writeOutput writes data to the file out, or to stdout if out is empty.
+func writeOutput(out string, data []byte) error {
+	if out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pf spec [flags]\n       pf client [flags]\n       pf ts [flags]\n       pf diff [-json] base head")
+	os.Exit(2)
+}
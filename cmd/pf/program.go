@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// program is the program generating code from the router, as routers can
+// only be built by compiling the package of their constructor. For a main
+// package, the program is added to the package as an init function exiting
+// before main runs. The output is written to the file named by PF_OUTPUT
+// rather than stdout, where the constructor may print.
+var program = template.Must(template.New("main").Parse(`package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TaeKwonZeus/pf"
+{{if not .Main}}
+	router {{printf "%q" .Package}}
+{{end -}}
+)
+
+func {{if .Main}}init{{else}}main{{end}}() {
+	r := {{if not .Main}}router.{{end}}{{.Func}}()
+	{{.Generate}}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(os.Getenv("PF_OUTPUT"), data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if {{.Strict}} && len(warnings) > 0 {
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, "warning:", w)
+		}
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+`))
+
+// runProgram builds and runs the program generating code from the router r
+// returned by fn in pkg. generate sets data, warnings and err.
+func runProgram(pkg, fn, generate string, strict bool) ([]byte, error) {
+	info, err := goList(pkg, "{{.ImportPath}}\n{{.Name}}\n{{.Dir}}\n{{.Module.Dir}}")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(info, "\n")
+	if len(fields) != 4 || fields[3] == "" {
+		return nil, fmt.Errorf("%s is not a package of a module", pkg)
+	}
+	importPath, name, pkgDir, moduleDir := fields[0], fields[1], fields[2], fields[3]
+
+	dir, err := os.MkdirTemp("", "pfgen")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var src bytes.Buffer
+	err = program.Execute(&src, map[string]any{
+		"Main":     name == "main",
+		"Package":  importPath,
+		"Func":     fn,
+		"Generate": generate,
+		"Strict":   strict,
+	})
+	if err != nil {
+		return nil, err
+	}
+	srcFile := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(srcFile, src.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+
+	// The program is added to the module with an overlay, so that it uses its
+	// dependencies without writing to the module: as a package in a directory
+	// that doesn't exist, or as a file of a main package named to sort last,
+	// for the init functions of the package to run first.
+	buildDir, file, target := moduleDir, filepath.Join(moduleDir, ".pfgen", "main.go"), "./.pfgen"
+	if name == "main" {
+		buildDir, file, target = pkgDir, filepath.Join(pkgDir, "zz_pfgen.go"), "."
+	}
+	overlay, err := json.Marshal(map[string]any{"Replace": map[string]string{file: srcFile}})
+	if err != nil {
+		return nil, err
+	}
+	overlayFile := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0o644); err != nil {
+		return nil, err
+	}
+
+	gen := filepath.Join(dir, "gen")
+	build := exec.Command("go", "build", "-overlay", overlayFile, "-o", gen, target)
+	build.Dir = buildDir
+	if out, err := build.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("building generator: %w\n%s", err, out)
+	}
+
+	output := filepath.Join(dir, "output")
+	var stderr bytes.Buffer
+	cmd := exec.Command(gen)
+	cmd.Env = append(os.Environ(), "PF_OUTPUT="+output)
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 3 {
+			return nil, fmt.Errorf("generated with warnings:\n%s", warnings(stderr.String()))
+		}
+		return nil, fmt.Errorf("generating: %w\n%s", err, stderr.String())
+	}
+	return os.ReadFile(output)
+}
+
+// warnings returns the warnings reported by the program, leaving out
+// the logs.
+func warnings(stderr string) string {
+	var lines []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.HasPrefix(line, "warning: ") {
+			lines = append(lines, strings.TrimPrefix(line, "warning: "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func goList(pkg, format string) (string, error) {
+	out, err := exec.Command("go", "list", "-f", format, pkg).Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return "", fmt.Errorf("go list %s: %s", pkg, exit.Stderr)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
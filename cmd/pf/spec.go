@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func runSpec(args []string) error {
+	flags := flag.NewFlagSet("pf spec", flag.ExitOnError)
+	pkg := flags.String("pkg", ".", "package of the router constructor")
+	fn := flags.String("func", "NewRouter", "router constructor, of type func() *pf.Router")
+	format := flags.String("format", "openapi.json", "spec format: swagger.json, swagger.yaml, openapi.json or openapi.yaml")
+	out := flags.String("o", "", "output file (default stdout)")
+	strict := flags.Bool("strict", false, "fail on generation warnings")
+	flags.Parse(args)
+
+	generate := fmt.Sprintf("data, warnings, err := pf.Spec(r, pf.SpecFormat(%q))", *format)
+	data, err := runProgram(*pkg, *fn, generate, *strict)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, data)
+}
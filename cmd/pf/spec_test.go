@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdir runs the test in dir, the working directory of the commands.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSpec(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "spec")
+	chdir(t, "testdata/app")
+
+	// NewRouter prints to stdout, which must stay out of the spec
+	if err := runSpec([]string{"-format", "openapi.json", "-o", out}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string
+		Paths   map[string]any
+	}
+	if err := json.Unmarshal(must(os.ReadFile(out)), &doc); err != nil || doc.OpenAPI != "3.1.0" || doc.Paths["/ping"] == nil {
+		t.Errorf("openapi.json = %+v, %v", doc, err)
+	}
+
+	if err := runSpec([]string{"-format", "swagger.yaml", "-o", out}); err != nil {
+		t.Fatal(err)
+	}
+	if yaml := string(must(os.ReadFile(out))); !strings.Contains(yaml, `swagger: "2.0"`) || !strings.Contains(yaml, "/ping:") || strings.Contains(yaml, "starting") {
+		t.Errorf("swagger.yaml = %s", yaml)
+	}
+
+	// Warnings fail strict generation, reported by the program with exit status 3
+	if err := runSpec([]string{"-func", "NewWarningRouter", "-o", out}); err != nil {
+		t.Errorf("non-strict: %v", err)
+	}
+	err := runSpec([]string{"-func", "NewWarningRouter", "-strict", "-o", out})
+	if err == nil || !strings.HasPrefix(err.Error(), "generated with warnings:\nswagger: support only for maps with string keys") {
+		t.Errorf("strict: %v", err)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,31 @@
+// Package app is a module with pf routers, for tests of the pf command.
+package app
+
+import (
+	"fmt"
+
+	"github.com/TaeKwonZeus/pf"
+)
+
+type Pong struct {
+	Message string `json:"message"`
+}
+
+// NewRouter prints to stdout, as constructors logging their setup do.
+func NewRouter() *pf.Router {
+	fmt.Println("starting")
+	r := pf.NewRouter()
+	pf.Get(r, "/ping", func(w pf.ResponseWriter[Pong], r *pf.Request[struct{}]) error {
+		return w.OK(Pong{Message: "pong"})
+	})
+	return r
+}
+
+// NewWarningRouter responds with a type missing from the spec.
+func NewWarningRouter() *pf.Router {
+	r := pf.NewRouter()
+	pf.Get(r, "/counts", func(w pf.ResponseWriter[map[int]int], r *pf.Request[struct{}]) error {
+		return w.OK(nil)
+	})
+	return r
+}
@@ -0,0 +1,18 @@
+package main
+
+import "flag"
+
+func runTypeScript(args []string) error {
+	flags := flag.NewFlagSet("pf ts", flag.ExitOnError)
+	pkg := flags.String("pkg", ".", "package of the router constructor")
+	fn := flags.String("func", "NewRouter", "router constructor, of type func() *pf.Router")
+	out := flags.String("o", "", "output file (default stdout)")
+	flags.Parse(args)
+
+	generate := "data, err := pf.GenerateTypeScript(r)\n\tvar warnings []string"
+	data, err := runProgram(*pkg, *fn, generate, false)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, data)
+}
@@ -0,0 +1,181 @@
+package pf
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder reads v from r in the format of a media type.
+type Decoder func(r io.Reader, v any) error
+
+type mediaDecoder struct {
+	mediaType string
+	decode    Decoder
+}
+
+// SetDecoder makes the handlers of r decode request bodies sent as mediaType
+// with dec, and adds mediaType to the types their operations consume. JSON
+// and form-urlencoded bodies are always accepted, and their decoders may be
+// replaced. Bodies of other types are rejected with 415 Unsupported Media
+// Type. Sub-routers inherit the decoders of their parent.
+//
+// Forms are only documented for struct bodies, and not in Swagger 2.0 specs,
+// which can't describe a body parameter sent as a form.
+//
+// DecodeXML and DecodeYAML are available as opt-ins:
+//
+//	pf.SetDecoder(r, "application/xml", pf.DecodeXML)
+func SetDecoder(r *Router, mediaType string, dec Decoder) {
+	r.decoders = slices.DeleteFunc(r.decoders, func(d mediaDecoder) bool { return d.mediaType == mediaType })
+	r.decoders = append(r.decoders, mediaDecoder{mediaType: mediaType, decode: dec})
+}
+
+// mediaDecoders returns the decoders available to the handlers of r: JSON,
+// form-urlencoded, then the decoders set on its furthest parent.
+func (r *Router) mediaDecoders() []mediaDecoder {
+	if r == nil {
+		return []mediaDecoder{
+			{mediaType: "application/json", decode: DecodeJSON},
+			{mediaType: "application/x-www-form-urlencoded", decode: DecodeForm},
+		}
+	}
+
+	decoders := r.parent.mediaDecoders()
+	for _, d := range r.decoders {
+		if i := slices.IndexFunc(decoders, func(p mediaDecoder) bool { return p.mediaType == d.mediaType }); i >= 0 {
+			decoders[i] = d
+		} else {
+			decoders = append(decoders, d)
+		}
+	}
+	return decoders
+}
+
+// decodeBody decodes the body r, sent with the Content-Type header
+// contentType, into v with the matching decoder. Bodies without a
+// Content-Type are decoded as JSON.
+func decodeBody(r io.Reader, contentType string, v any, decoders []mediaDecoder) error {
+	mediaType := "application/json"
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnsupportedMediaType, err)
+		}
+	}
+
+	i := slices.IndexFunc(decoders, func(d mediaDecoder) bool { return d.mediaType == mediaType })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	return decoders[i].decode(r, v)
+}
+
+// describeDecoders sets the types consumed by op, which has a request body of
+// type typ, to the media types of the decoders of r. Forms are only consumed
+// by struct bodies.
+func describeDecoders(op *spec.Operation, r *Router, typ reflect.Type) {
+	op.Consumes = nil
+	for _, d := range r.mediaDecoders() {
+		if isFormType(d.mediaType) && derefType(typ).Kind() != reflect.Struct {
+			continue
+		}
+		op.Consumes = append(op.Consumes, d.mediaType)
+	}
+}
+
+// isFormType reports whether mediaType is a type of HTML form.
+func isFormType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// DecodeJSON decodes v from JSON.
+func DecodeJSON(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// DecodeXML decodes v from XML with encoding/xml.
+func DecodeXML(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// DecodeYAML decodes v from YAML. Fields are named by their json tags, as in
+// JSON requests.
+func DecodeYAML(r io.Reader, v any) error {
+	// Round trip through JSON for the names and decoding of fields
+	var doc any
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// DecodeForm decodes v, a pointer to a struct, from a form-urlencoded body.
+// Fields are populated from the values named by their `form:"name"` tags,
+// or their JSON names, converting them like parameters. Fields bound to
+// parameters and fields tagged `form:"-"` are skipped.
+func DecodeForm(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("pf: cannot decode a form into %T", v)
+	}
+	return decodeForm(rv.Elem(), values)
+}
+
+func decodeForm(v reflect.Value, values url.Values) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if _, _, ok := paramTag(field); ok {
+			continue
+		}
+
+		name, tagged := field.Tag.Lookup("form")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			if err := decodeForm(v.Field(i), values); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			if field.Tag.Get("json") == "-" {
+				continue
+			}
+			name = jsonName(field)
+		}
+
+		if vals := values[name]; len(vals) > 0 {
+			if err := setValue(v.Field(i), vals); err != nil {
+				return fmt.Errorf("form field %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
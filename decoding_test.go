@@ -0,0 +1,104 @@
+package pf
+
+import (
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type OrderForm struct {
+	Tenant   string   `header:"X-Tenant"`
+	Item     string   `form:"item" json:"item"`
+	Quantity int      `json:"quantity"`
+	Tags     []string `form:"tag" json:"tags"`
+	Secret   string   `form:"-" json:"secret"`
+}
+
+func TestDecoders(t *testing.T) {
+	var got OrderForm
+	r := NewRouter()
+	SetDecoder(r, "application/yaml", DecodeYAML)
+	Post(r, "/orders", func(w ResponseWriter[struct{}], r *Request[OrderForm]) error {
+		got = r.Body
+		return nil
+	})
+
+	tests := []struct {
+		contentType, body string
+		status            int
+	}{
+		{"", `{"item":"beer","quantity":2,"tags":["a","b"]}`, 200},
+		{"application/json; charset=utf-8", `{"item":"beer","quantity":2,"tags":["a","b"]}`, 200},
+		{"application/x-www-form-urlencoded", "item=beer&quantity=2&tag=a&tag=b&secret=x", 200},
+		{"application/yaml", "item: beer\nquantity: 2\ntags: [a, b]\n", 200},
+		{"application/x-www-form-urlencoded", "item=beer&quantity=two", 400},
+		{"application/xml", "<OrderForm/>", 415},
+	}
+
+	for _, test := range tests {
+		got = OrderForm{}
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(test.body))
+		req.Header.Set("X-Tenant", "acme")
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: status = %d, want %d: %s", test.contentType, rec.Code, test.status, rec.Body.String())
+			continue
+		}
+		if test.status == 200 && (got.Item != "beer" || got.Quantity != 2 || !slices.Equal(got.Tags, []string{"a", "b"}) || got.Tenant != "acme" || got.Secret != "") {
+			t.Errorf("%s: body = %+v", test.contentType, got)
+		}
+	}
+
+	Post(r, "/tags", func(w ResponseWriter[struct{}], r *Request[[]string]) error {
+		return nil
+	})
+
+	// Swagger 2.0 body parameters can't be forms
+	op := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/orders"].Post
+	if want := []string{"application/json", "application/yaml"}; !slices.Equal(op.Consumes, want) {
+		t.Errorf("consumes = %v, want %v", op.Consumes, want)
+	}
+
+	doc := must(generateOpenAPI(r, new(SwaggerInfo), nil))
+	for path, want := range map[string][]string{
+		"/orders": {"application/json", "application/x-www-form-urlencoded", "application/yaml"},
+		"/tags":   {"application/json", "application/yaml"},
+	} {
+		content := doc.Paths[path]["post"].RequestBody.Content
+		if got := slices.Sorted(maps.Keys(content)); !slices.Equal(got, want) {
+			t.Errorf("%s: request body types = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMultipartRequest(t *testing.T) {
+	var got *multipart.Form
+	r := NewRouter()
+	Post(r, "/upload", func(w ResponseWriter[struct{}], r *Request[*multipart.Form]) error {
+		got = r.Body
+		return nil
+	})
+
+	var body strings.Builder
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "beer")
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != 200 || got == nil || got.Value["name"][0] != "beer" {
+		t.Errorf("status = %d, form = %v", rec.Code, got)
+	}
+}
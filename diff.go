@@ -0,0 +1,631 @@
+package pf
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a difference between two specs.
+type Change struct {
+	// Breaking is set for changes that may break existing clients.
+	Breaking bool `json:"breaking"`
+
+	// Location is the changed operation, parameter, definition or field,
+	// such as "GET /users/{id} response 200" or
+	// "response definition User .name".
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "breaking"
+	}
+	return fmt.Sprintf("%s: %s: %s", kind, c.Location, c.Message)
+}
+
+// SpecDiff lists the changes between two specs.
+type SpecDiff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Breaking reports whether any of the changes is breaking.
+func (d SpecDiff) Breaking() bool {
+	return slices.ContainsFunc(d.Changes, func(c Change) bool { return c.Breaking })
+}
+
+// DiffSpecs compares the specs base and head, Swagger 2.0 or OpenAPI 3.1
+// written in JSON or YAML by AddSwagger, AddOpenAPI or WriteSpec. Removed
+// operations, responses and fields, newly required request parameters and
+// fields, type changes and enums narrowed in requests or widened in responses
+// are breaking. Changes to definitions shared by several operations are
+// reported once, at the definition.
+func DiffSpecs(base, head []byte) (SpecDiff, error) {
+	baseSpec, err := parseSpec(base)
+	if err != nil {
+		return SpecDiff{}, fmt.Errorf("base spec: %w", err)
+	}
+	headSpec, err := parseSpec(head)
+	if err != nil {
+		return SpecDiff{}, fmt.Errorf("head spec: %w", err)
+	}
+
+	d := differ{base: baseSpec, head: headSpec, changes: []Change{}, visited: make(map[definitionPair]bool)}
+	d.paths()
+	d.definitions()
+	return SpecDiff{Changes: d.changes}, nil
+}
+
+// parseSpec parses a Swagger 2.0 or OpenAPI 3.1 spec, converting the latter
+// to the Swagger 2.0 model compared by differ.
+func parseSpec(data []byte) (*spec.Swagger, error) {
+	// YAML is a superset of JSON
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var s *spec.Swagger
+	var err error
+	switch version, _ := doc["openapi"].(string); {
+	case doc["swagger"] == "2.0":
+		s, err = parseSwagger(doc)
+	case strings.HasPrefix(version, "3."):
+		s, err = parseOpenAPI(doc)
+	default:
+		return nil, fmt.Errorf("not a Swagger 2.0 or OpenAPI 3 spec")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Paths == nil {
+		s.Paths = new(spec.Paths)
+	}
+	return s, nil
+}
+
+func parseSwagger(doc map[string]any) (*spec.Swagger, error) {
+	var s spec.Swagger
+	if err := remarshal(doc, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// parseOpenAPI converts the parts of an OpenAPI 3 spec compared by differ
+// to Swagger 2.0: schemas become definitions, request bodies become body
+// parameters, and the JSON content of bodies is preferred to other media
+// types.
+func parseOpenAPI(doc map[string]any) (*spec.Swagger, error) {
+	normalizeSchemas(doc)
+
+	// Path items also hold shared parameters, servers and descriptions
+	if paths, ok := doc["paths"].(map[string]any); ok {
+		for _, item := range paths {
+			if item, ok := item.(map[string]any); ok {
+				maps.DeleteFunc(item, func(key string, _ any) bool {
+					return !slices.Contains(openAPIMethods, key)
+				})
+			}
+		}
+	}
+
+	var in openAPI
+	if err := remarshal(doc, &in); err != nil {
+		return nil, err
+	}
+
+	s := &spec.Swagger{SwaggerProps: spec.SwaggerProps{
+		Definitions: make(spec.Definitions, len(in.Components.Schemas)),
+		Paths:       &spec.Paths{Paths: make(map[string]spec.PathItem, len(in.Paths))},
+	}}
+	for name, schema := range in.Components.Schemas {
+		var def spec.Schema
+		if err := remarshal(schema, &def); err != nil {
+			return nil, err
+		}
+		s.Definitions[name] = def
+	}
+
+	for path, methods := range in.Paths {
+		var item spec.PathItem
+		for method, op := range methods {
+			converted, err := swaggerOperation(op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			switch method {
+			case "get":
+				item.Get = converted
+			case "put":
+				item.Put = converted
+			case "post":
+				item.Post = converted
+			case "delete":
+				item.Delete = converted
+			case "options":
+				item.Options = converted
+			case "head":
+				item.Head = converted
+			case "patch":
+				item.Patch = converted
+			}
+		}
+		s.Paths.Paths[path] = item
+	}
+
+	return s, nil
+}
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// swaggerOperation converts the parameters, request body and responses of
+// an OpenAPI operation.
+func swaggerOperation(op *openAPIOperation) (*spec.Operation, error) {
+	out := new(spec.Operation)
+	for _, param := range op.Parameters {
+		var schema spec.Schema
+		if err := remarshal(param.Schema, &schema); err != nil {
+			return nil, err
+		}
+		p := spec.Parameter{ParamProps: spec.ParamProps{
+			Name:     param.Name,
+			In:       param.In,
+			Required: param.Required,
+			Schema:   &schema,
+		}}
+		out.Parameters = append(out.Parameters, p)
+	}
+
+	if op.RequestBody != nil {
+		schema, err := contentSchema(op.RequestBody.Content)
+		if err != nil {
+			return nil, err
+		}
+		body := spec.BodyParam("body", schema)
+		body.Required = op.RequestBody.Required
+		out.Parameters = append(out.Parameters, *body)
+	}
+
+	out.Responses = &spec.Responses{ResponsesProps: spec.ResponsesProps{
+		StatusCodeResponses: make(map[int]spec.Response, len(op.Responses)),
+	}}
+	for status, res := range op.Responses {
+		schema, err := contentSchema(res.Content)
+		if err != nil {
+			return nil, err
+		}
+		converted := spec.Response{ResponseProps: spec.ResponseProps{Schema: schema}}
+		if status == "default" {
+			out.Responses.Default = &converted
+			continue
+		}
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return nil, fmt.Errorf("response %q: %w", status, err)
+		}
+		out.Responses.StatusCodeResponses[code] = converted
+	}
+
+	return out, nil
+}
+
+// contentSchema returns the schema of the JSON media type of content, or of
+// the first media type, or nil for empty content.
+func contentSchema(content map[string]openAPIMediaType) (*spec.Schema, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	media, ok := content["application/json"]
+	if !ok {
+		media = content[slices.Sorted(maps.Keys(content))[0]]
+	}
+	if media.Schema == nil {
+		return new(spec.Schema), nil
+	}
+
+	schema := new(spec.Schema)
+	if err := remarshal(media.Schema, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// normalizeSchemas rewrites the JSON Schema 2020-12 schemas of an OpenAPI 3
+// document in place to Swagger 2.0: references point to definitions, and
+// null types are replaced with x-nullable.
+func normalizeSchemas(v any) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			normalizeSchemas(item)
+		}
+
+	case map[string]any:
+		for _, item := range v {
+			normalizeSchemas(item)
+		}
+
+		if ref, ok := v["$ref"].(string); ok {
+			v["$ref"] = strings.Replace(ref, "#/components/schemas/", "#/definitions/", 1)
+		}
+
+		if types, ok := v["type"].([]any); ok && slices.Contains(types, "null") {
+			types = slices.DeleteFunc(types, func(t any) bool { return t == "null" })
+			if len(types) == 1 {
+				v["type"] = types[0]
+			} else {
+				v["type"] = types
+			}
+			v["x-nullable"] = true
+		}
+
+		// Nullable references: {"anyOf": [{"$ref": ...}, {"type": "null"}]}
+		if anyOf, ok := v["anyOf"].([]any); ok {
+			rest := slices.DeleteFunc(slices.Clone(anyOf), func(sub any) bool {
+				s, ok := sub.(map[string]any)
+				return ok && len(s) == 1 && s["type"] == "null"
+			})
+			if len(rest) == 1 && len(rest) < len(anyOf) {
+				delete(v, "anyOf")
+				v["allOf"] = rest
+				v["x-nullable"] = true
+			}
+		}
+	}
+}
+
+// remarshal converts v to out through JSON.
+func remarshal(v, out any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// direction is the direction in which a schema is sent.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+type differ struct {
+	base, head *spec.Swagger
+	changes    []Change
+
+	// visited holds the pairs of definitions referenced by the compared
+	// schemas, which are compared once, also stopping at recursive types.
+	visited map[definitionPair]bool
+
+	// pending holds the visited pairs not compared yet.
+	pending []definitionPair
+}
+
+// definitionPair is a definition of base compared to one of head, referenced
+// by the same schema.
+type definitionPair struct {
+	base, head string
+	dir        direction
+}
+
+func (p definitionPair) location() string {
+	dir := "request"
+	if p.dir == response {
+		dir = "response"
+	}
+	if p.base == p.head {
+		return fmt.Sprintf("%s definition %s", dir, p.head)
+	}
+	return fmt.Sprintf("%s definition %s (was %s)", dir, p.head, p.base)
+}
+
+func (d *differ) add(breaking bool, loc, format string, args ...any) {
+	d.changes = append(d.changes, Change{
+		Breaking: breaking,
+		Location: loc,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) paths() {
+	for _, path := range sortedKeys(d.base.Paths.Paths, d.head.Paths.Paths) {
+		baseOps := operationsByMethod(d.base.Paths.Paths[path])
+		headOps := operationsByMethod(d.head.Paths.Paths[path])
+
+		for _, method := range sortedKeys(baseOps, headOps) {
+			loc := method + " " + path
+			baseOp, headOp := baseOps[method], headOps[method]
+			switch {
+			case headOp == nil:
+				d.add(true, loc, "operation removed")
+			case baseOp == nil:
+				d.add(false, loc, "operation added")
+			default:
+				d.operation(loc, baseOp, headOp)
+			}
+		}
+	}
+}
+
+func operationsByMethod(item spec.PathItem) map[string]*spec.Operation {
+	ops := map[string]*spec.Operation{
+		"GET":     item.Get,
+		"PUT":     item.Put,
+		"POST":    item.Post,
+		"DELETE":  item.Delete,
+		"OPTIONS": item.Options,
+		"HEAD":    item.Head,
+		"PATCH":   item.Patch,
+	}
+	maps.DeleteFunc(ops, func(_ string, op *spec.Operation) bool { return op == nil })
+	return ops
+}
+
+func (d *differ) operation(loc string, base, head *spec.Operation) {
+	baseParams := paramsByKey(base.Parameters)
+	headParams := paramsByKey(head.Parameters)
+
+	for _, key := range sortedKeys(baseParams, headParams) {
+		baseParam, inBase := baseParams[key]
+		headParam, inHead := headParams[key]
+		paramLoc := loc + " " + key
+
+		switch {
+		case !inHead:
+			d.add(false, paramLoc, "parameter removed")
+		case !inBase:
+			d.add(headParam.Required, paramLoc, "%s parameter added", requiredWord(headParam.Required))
+		case headParam.In == "body":
+			if headParam.Required && !baseParam.Required {
+				d.add(true, paramLoc, "request body became required")
+			}
+			d.schema(loc+" request body", *schemaOrEmpty(baseParam.Schema), *schemaOrEmpty(headParam.Schema), request)
+		default:
+			if headParam.Required && !baseParam.Required {
+				d.add(true, paramLoc, "parameter became required")
+			}
+			baseSchema, headSchema := paramSchemaOf(baseParam), paramSchemaOf(headParam)
+			if d.simple(paramLoc, baseSchema, headSchema, request) && baseSchema.Items != nil && headSchema.Items != nil {
+				d.simple(paramLoc+"[]", *baseSchema.Items.Schema, *headSchema.Items.Schema, request)
+			}
+		}
+	}
+
+	baseRes, headRes := responsesByStatus(base), responsesByStatus(head)
+	for _, status := range sortedKeys(baseRes, headRes) {
+		resLoc := loc + " response " + status
+		baseR, inBase := baseRes[status]
+		headR, inHead := headRes[status]
+
+		switch {
+		case !inHead:
+			d.add(true, resLoc, "response removed")
+		case !inBase:
+			d.add(false, resLoc, "response added")
+		default:
+			switch {
+			case baseR.Schema != nil && headR.Schema == nil:
+				d.add(true, resLoc, "response body removed")
+			case baseR.Schema == nil && headR.Schema != nil:
+				d.add(false, resLoc, "response body added")
+			case baseR.Schema != nil:
+				d.schema(resLoc, *baseR.Schema, *headR.Schema, response)
+			}
+		}
+	}
+}
+
+func paramsByKey(params []spec.Parameter) map[string]spec.Parameter {
+	out := make(map[string]spec.Parameter, len(params))
+	for _, p := range params {
+		if p.In == "body" {
+			out["body"] = p
+		} else {
+			out[p.In+" "+p.Name] = p
+		}
+	}
+	return out
+}
+
+func responsesByStatus(op *spec.Operation) map[string]spec.Response {
+	out := make(map[string]spec.Response)
+	if op.Responses == nil {
+		return out
+	}
+	for status, res := range op.Responses.StatusCodeResponses {
+		out[strconv.Itoa(status)] = res
+	}
+	if op.Responses.Default != nil {
+		out["default"] = *op.Responses.Default
+	}
+	return out
+}
+
+// paramSchemaOf returns the schema of a non-body parameter, converted from
+// OpenAPI 3 or from its Swagger 2.0 properties.
+func paramSchemaOf(p spec.Parameter) spec.Schema {
+	if p.Schema != nil {
+		return *p.Schema
+	}
+
+	var s spec.Schema
+	s.Typed(p.Type, p.Format)
+	s.Enum = p.Enum
+	if p.Items != nil {
+		var items spec.Schema
+		items.Typed(p.Items.Type, p.Items.Format)
+		items.Enum = p.Items.Enum
+		s.Items = &spec.SchemaOrArray{Schema: &items}
+	}
+	return s
+}
+
+func schemaOrEmpty(s *spec.Schema) *spec.Schema {
+	if s == nil {
+		return new(spec.Schema)
+	}
+	return s
+}
+
+// resolve follows the reference of s, including references wrapped in allOf
+// to carry metadata, returning the name of the definition.
+func resolve(s spec.Schema, root *spec.Swagger) (spec.Schema, string) {
+	if len(s.AllOf) == 1 && len(s.Type) == 0 {
+		s = s.AllOf[0]
+	}
+	name, ok := strings.CutPrefix(s.Ref.String(), "#/definitions/")
+	if !ok {
+		return s, ""
+	}
+	return root.Definitions[name], name
+}
+
+// schema compares the schemas of a body sent in dir.
+func (d *differ) schema(loc string, base, head spec.Schema, dir direction) {
+	if base.Extensions["x-nullable"] != true && head.Extensions["x-nullable"] == true && dir == response {
+		d.add(true, loc, "became nullable")
+	}
+
+	base, baseName := resolve(base, d.base)
+	head, headName := resolve(head, d.head)
+	if baseName != "" && headName != "" {
+		// Compared by definitions, for the changes to be reported once
+		pair := definitionPair{baseName, headName, dir}
+		if !d.visited[pair] {
+			d.visited[pair] = true
+			d.pending = append(d.pending, pair)
+		}
+		return
+	}
+
+	if !d.simple(loc, base, head, dir) {
+		return
+	}
+
+	if base.Items != nil && base.Items.Schema != nil && head.Items != nil && head.Items.Schema != nil {
+		d.schema(loc+"[]", *base.Items.Schema, *head.Items.Schema, dir)
+	}
+	if base.AdditionalProperties != nil && base.AdditionalProperties.Schema != nil &&
+		head.AdditionalProperties != nil && head.AdditionalProperties.Schema != nil {
+		d.schema(loc+"{}", *base.AdditionalProperties.Schema, *head.AdditionalProperties.Schema, dir)
+	}
+
+	for _, name := range sortedKeys(base.Properties, head.Properties) {
+		propLoc := loc + " ." + name
+		baseProp, inBase := base.Properties[name]
+		headProp, inHead := head.Properties[name]
+		baseRequired := slices.Contains(base.Required, name)
+		headRequired := slices.Contains(head.Required, name)
+
+		switch {
+		case !inHead:
+			d.add(true, propLoc, "field removed")
+		case !inBase:
+			// New required fields must be sent by existing clients
+			d.add(dir == request && headRequired, propLoc, "%s field added", requiredWord(headRequired))
+		default:
+			if dir == request && headRequired && !baseRequired {
+				d.add(true, propLoc, "field became required")
+			}
+			if dir == response && baseRequired && !headRequired {
+				d.add(true, propLoc, "field became optional")
+			}
+			d.schema(propLoc, baseProp, headProp, dir)
+		}
+	}
+}
+
+// definitions compares the pairs of definitions referenced by the compared
+// schemas, including those referenced by the definitions.
+func (d *differ) definitions() {
+	for len(d.pending) > 0 {
+		pending := d.pending
+		d.pending = nil
+		slices.SortFunc(pending, func(a, b definitionPair) int {
+			return strings.Compare(a.location(), b.location())
+		})
+		for _, pair := range pending {
+			d.schema(pair.location(), d.base.Definitions[pair.base], d.head.Definitions[pair.head], pair.dir)
+		}
+	}
+}
+
+// simple compares the type, format and enum of schemas, and reports whether
+// their types are the same.
+func (d *differ) simple(loc string, base, head spec.Schema, dir direction) bool {
+	baseType, headType := strings.Join(base.Type, ","), strings.Join(head.Type, ",")
+	if baseType != headType {
+		d.add(true, loc, "type changed from %s to %s", typeWord(baseType), typeWord(headType))
+		return false
+	}
+	if base.Format != head.Format {
+		d.add(true, loc, "format changed from %q to %q", base.Format, head.Format)
+	}
+
+	removed := missingValues(base.Enum, head.Enum)
+	added := missingValues(head.Enum, base.Enum)
+	switch {
+	case len(base.Enum) > 0 && len(head.Enum) == 0:
+		d.add(dir == response, loc, "enum removed")
+	case len(base.Enum) == 0 && len(head.Enum) > 0:
+		d.add(dir == request, loc, "enum added")
+	default:
+		if len(removed) > 0 {
+			d.add(dir == request, loc, "enum values removed: %v", removed)
+		}
+		if len(added) > 0 {
+			d.add(dir == response, loc, "enum values added: %v", added)
+		}
+	}
+
+	return true
+}
+
+// missingValues returns the values of a missing from b.
+func missingValues(a, b []any) []any {
+	var out []any
+	for _, v := range a {
+		if !slices.ContainsFunc(b, func(w any) bool { return fmt.Sprint(v) == fmt.Sprint(w) }) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func typeWord(typ string) string {
+	if typ == "" {
+		return "any"
+	}
+	return typ
+}
+
+func requiredWord(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
+
+// sortedKeys returns the sorted union of the keys of a and b.
+func sortedKeys[V any](a, b map[string]V) []string {
+	keys := slices.Collect(maps.Keys(a))
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package pf
+
+import (
+	"slices"
+	"testing"
+)
+
+type OrderV1 struct {
+	ID     int      `json:"id"`
+	Status string   `json:"status" enum:"open,closed"`
+	Note   string   `json:"note"`
+	Items  []string `json:"items"`
+}
+
+type OrderV2 struct {
+	ID     string   `json:"id"`
+	Status string   `json:"status" enum:"open,closed,lost"`
+	Items  []string `json:"items"`
+	Total  int      `json:"total"`
+}
+
+type CreateOrderV1 struct {
+	Kind string `json:"kind" enum:"a,b"`
+	Page int    `query:"page"`
+}
+
+type CreateOrderV2 struct {
+	Kind  string `json:"kind" enum:"a"`
+	Email string `json:"email"`
+	Page  int    `query:"page" validate:"required"`
+}
+
+func TestDiffSpecs(t *testing.T) {
+	base := NewRouter()
+	Get(base, "/orders/{id}", func(w ResponseWriter[OrderV1], r *Request[struct{}]) error { return nil })
+	Post(base, "/orders", func(w ResponseWriter[OrderV1], r *Request[CreateOrderV1]) error { return nil })
+	Delete(base, "/orders/{id}", func(w ResponseWriter[struct{}], r *Request[struct{}]) error { return nil })
+
+	head := NewRouter()
+	Get(head, "/orders/{id}", func(w ResponseWriter[OrderV2], r *Request[struct{}]) error { return nil })
+	Post(head, "/orders", func(w ResponseWriter[OrderV2], r *Request[CreateOrderV2]) error { return nil })
+	Get(head, "/orders", func(w ResponseWriter[[]OrderV2], r *Request[struct{}]) error { return nil })
+
+	want := []string{
+		"non-breaking: GET /orders: operation added",
+		"breaking: POST /orders query page: parameter became required",
+		"breaking: DELETE /orders/{id}: operation removed",
+		"breaking: request definition CreateOrderV2 (was CreateOrderV1) .email: required field added",
+		"breaking: request definition CreateOrderV2 (was CreateOrderV1) .kind: enum values removed: [b]",
+		"breaking: response definition OrderV2 (was OrderV1) .id: type changed from integer to string",
+		"breaking: response definition OrderV2 (was OrderV1) .note: field removed",
+		"breaking: response definition OrderV2 (was OrderV1) .status: enum values added: [lost]",
+		"non-breaking: response definition OrderV2 (was OrderV1) .total: required field added",
+	}
+
+	for _, formats := range [][2]SpecFormat{{SwaggerJSON, SwaggerYAML}, {OpenAPIJSON, OpenAPIYAML}} {
+		baseSpec, _, _ := Spec(base, formats[0])
+		headSpec, _, _ := Spec(head, formats[1])
+		diff, err := DiffSpecs(baseSpec, headSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, c := range diff.Changes {
+			got = append(got, c.String())
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s changes:\n%s", formats[0], got)
+		}
+		if !diff.Breaking() {
+			t.Errorf("%s: expected breaking changes", formats[0])
+		}
+
+		same, err := DiffSpecs(baseSpec, baseSpec)
+		if err != nil || len(same.Changes) != 0 {
+			t.Errorf("%s: diff of identical specs = %v, %v", formats[0], same.Changes, err)
+		}
+	}
+}
+
+type CustomerV1 struct {
+	Name    string  `json:"name"`
+	Manager *Author `json:"manager"`
+}
+
+type CustomerV2 struct {
+	Name    *string `json:"name"`
+	Manager *Author `json:"manager"`
+}
+
+func TestDiffNullable(t *testing.T) {
+	base := NewRouter()
+	Get(base, "/customer", func(w ResponseWriter[CustomerV1], r *Request[struct{}]) error { return nil })
+	head := NewRouter()
+	Get(head, "/customer", func(w ResponseWriter[CustomerV2], r *Request[struct{}]) error { return nil })
+
+	for _, format := range []SpecFormat{SwaggerJSON, OpenAPIJSON} {
+		baseSpec, _, _ := Spec(base, format)
+		headSpec, _, _ := Spec(head, format)
+		diff, err := DiffSpecs(baseSpec, headSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, c := range diff.Changes {
+			got = append(got, c.String())
+		}
+		want := []string{
+			"breaking: response definition CustomerV2 (was CustomerV1) .name: field became optional",
+			"breaking: response definition CustomerV2 (was CustomerV1) .name: became nullable",
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s changes:\n%s", format, got)
+		}
+	}
+}
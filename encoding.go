@@ -0,0 +1,286 @@
+package pf
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoder writes v to w in the format of a media type.
+type Encoder func(w io.Writer, v any) error
+
+type mediaEncoder struct {
+	mediaType string
+	encode    Encoder
+}
+
+// SetEncoder makes ResponseWriter.Respond in the handlers of r able to encode
+// responses as mediaType with enc. JSON is always available, and may be
+// replaced. Sub-routers inherit the encoders of their parent.
+//
+// mediaType is added to the types produced by the JSON operations of r whose
+// response type enc can encode, which is checked with a zero value. As the
+// spec can't tell which handlers use Respond, this includes handlers
+// responding with OK or JSON, which always send JSON. Document those with
+// WithProduces("application/json"), which replaces the encoded types.
+//
+// EncodeXML, EncodeYAML and EncodeCSV are available as opt-ins; formats such
+// as MessagePack and CBOR are registered with an encoder from the library of
+// choice:
+//
+//	pf.SetEncoder(r, "application/xml", pf.EncodeXML)
+//	pf.SetEncoder(r, "application/msgpack", func(w io.Writer, v any) error {
+//		return msgpack.NewEncoder(w).Encode(v)
+//	})
+func SetEncoder(r *Router, mediaType string, enc Encoder) {
+	r.encoders = slices.DeleteFunc(r.encoders, func(e mediaEncoder) bool { return e.mediaType == mediaType })
+	r.encoders = append(r.encoders, mediaEncoder{mediaType: mediaType, encode: enc})
+}
+
+// mediaEncoders returns the encoders available to the handlers of r, in the
+// order of preference: JSON, then the encoders set on its furthest parent.
+func (r *Router) mediaEncoders() []mediaEncoder {
+	if r == nil {
+		return []mediaEncoder{{mediaType: "application/json", encode: EncodeJSON}}
+	}
+
+	encoders := r.parent.mediaEncoders()
+	for _, e := range r.encoders {
+		if i := slices.IndexFunc(encoders, func(p mediaEncoder) bool { return p.mediaType == e.mediaType }); i >= 0 {
+			encoders[i] = e
+		} else {
+			encoders = append(encoders, e)
+		}
+	}
+	return encoders
+}
+
+// negotiate returns the encoder of encoders best matching the Accept header
+// accept, or false if the client accepts none of them.
+func negotiate(accept string, encoders []mediaEncoder) (mediaEncoder, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return encoders[0], true
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	var best mediaEncoder
+	bestQ := 0.0
+	for _, e := range encoders {
+		typ, subtype, _ := strings.Cut(e.mediaType, "/")
+
+		// The most specific range matching the media type applies
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := -1
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// describeEncoders adds the media types of the encoders of r able to encode
+// responses of type typ to the types produced by op, along JSON. It is called
+// before the props of the handler are applied, so that WithProduces replaces
+// them.
+func describeEncoders(op *spec.Operation, r *Router, typ reflect.Type) {
+	if r == nil || !contains(op.Produces, "application/json") {
+		return
+	}
+	for _, e := range r.mediaEncoders() {
+		if !contains(op.Produces, e.mediaType) && e.supports(typ) {
+			op.Produces = append(op.Produces, e.mediaType)
+		}
+	}
+}
+
+// supports reports whether e encodes the zero value of typ, or of the type it
+// points to, without error.
+func (e mediaEncoder) supports(typ reflect.Type) (ok bool) {
+	v := reflect.New(typ).Elem()
+	if typ.Kind() == reflect.Pointer {
+		v = reflect.New(typ.Elem())
+	}
+
+	// Encoders registered by users may not expect zero values
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return e.encode(io.Discard, v.Interface()) == nil
+}
+
+// EncodeJSON encodes v as JSON.
+func EncodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// EncodeXML encodes v as XML with encoding/xml. Slices and arrays, which
+// encoding/xml writes as an element per item, are wrapped in an items root
+// element.
+func EncodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	isList := rv.Kind() == reflect.Array || rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8
+	if !isList {
+		return enc.Encode(v)
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: "items"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// EncodeYAML encodes v as YAML. Fields are named by their json tags, as in
+// JSON responses.
+func EncodeYAML(w io.Writer, v any) error {
+	// Round trip through JSON for the names and encoding of fields
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return yaml.NewEncoder(w).Encode(doc)
+}
+
+// EncodeCSV encodes v, a [][]string or a slice of structs, as CSV. Slices of
+// structs get a header row with the JSON names of the fields, and a row per
+// item with the fields formatted like parameters. Structs with fields that
+// aren't formatted like parameters, such as nested structs and maps, are
+// rejected.
+func EncodeCSV(w io.Writer, v any) error {
+	cw := csv.NewWriter(w)
+	if records, ok := v.([][]string); ok {
+		return cw.WriteAll(records)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || derefType(rv.Type().Elem()).Kind() != reflect.Struct {
+		return fmt.Errorf("pf: cannot encode %T as CSV", v)
+	}
+
+	elem := derefType(rv.Type().Elem())
+	fields := jsonFields(elem)
+	header := make([]string, len(fields))
+	index := make([][]int, len(fields))
+	for i, f := range fields {
+		if !csvCell(f.field.Type) {
+			return fmt.Errorf("pf: cannot encode field %s of %s as CSV", f.field.Name, elem)
+		}
+		header[i] = f.name
+		sf, _ := elem.FieldByName(f.field.Name)
+		index[i] = sf.Index
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		for item.Kind() == reflect.Pointer && !item.IsNil() {
+			item = item.Elem()
+		}
+		record := make([]string, len(fields))
+		if item.Kind() == reflect.Struct {
+			for j := range fields {
+				// Fields promoted from nil embedded pointers stay empty
+				if fv, err := item.FieldByIndexErr(index[j]); err == nil {
+					record[j] = FormatParam(fv.Interface())
+				}
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell reports whether values of typ are formatted as a CSV cell: scalars
+// and encoding.TextMarshaler implementations.
+func csvCell(typ reflect.Type) bool {
+	typ = derefType(typ)
+	if reflect.PointerTo(typ).Implements(textMarshalerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// writeEncoded sends an HTTP response with the status code status and
+// response encoded with e.
+func writeEncoded(w http.ResponseWriter, status int, e mediaEncoder, response any) error {
+	w.Header().Set("Content-Type", e.mediaType)
+	w.WriteHeader(status)
+	return e.encode(w, response)
+}
@@ -0,0 +1,113 @@
+package pf
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type Beer struct {
+	Name string  `json:"name" xml:"name"`
+	ABV  float64 `json:"abv" xml:"abv"`
+}
+
+type BeerOrder struct {
+	ID   int  `json:"id" xml:"id"`
+	Beer Beer `json:"beer" xml:"beer"`
+}
+
+func TestNegotiate(t *testing.T) {
+	encoders := []mediaEncoder{
+		{mediaType: "application/json"},
+		{mediaType: "application/xml"},
+		{mediaType: "text/csv"},
+	}
+
+	for accept, want := range map[string]string{
+		"":                "application/json",
+		"*/*":             "application/json",
+		"application/xml": "application/xml",
+		"text/*":          "text/csv",
+		"application/json;q=0.5, application/xml":   "application/xml",
+		"application/*;q=0.1, application/json;q=0": "application/xml",
+		"text/html, */*;q=0.1":                      "application/json",
+		"image/png":                                 "",
+	} {
+		e, ok := negotiate(accept, encoders)
+		if e.mediaType != want || ok != (want != "") {
+			t.Errorf("negotiate(%q) = %q, %v, want %q", accept, e.mediaType, ok, want)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	r := NewRouter()
+	SetEncoder(r, "application/xml", EncodeXML)
+	Route(r, "/beers", func(r *Router) {
+		SetEncoder(r, "text/csv", EncodeCSV)
+		SetEncoder(r, "application/yaml", EncodeYAML)
+		Get(r, "/", func(w ResponseWriter[[]Beer], r *Request[struct{}]) error {
+			return w.Respond(http.StatusOK, []Beer{{Name: "Stout", ABV: 4.2}, {Name: "Lager", ABV: 5}})
+		})
+		Get(r, "/{name}", func(w ResponseWriter[Beer], r *Request[struct{}]) error {
+			return w.OK(Beer{Name: "Stout", ABV: 4.2})
+		}, WithProduces("application/json"))
+		Get(r, "/orders", func(w ResponseWriter[[]BeerOrder], r *Request[struct{}]) error {
+			return w.Respond(http.StatusOK, []BeerOrder{{ID: 1}})
+		})
+		Get(r, "/stock", func(w ResponseWriter[map[string]int], r *Request[struct{}]) error {
+			return w.Respond(http.StatusOK, map[string]int{"Stout": 3})
+		})
+	})
+
+	for accept, want := range map[string]string{
+		"":                 `[{"name":"Stout","abv":4.2},{"name":"Lager","abv":5}]` + "\n",
+		"application/xml":  xml.Header + "<items><Beer><name>Stout</name><abv>4.2</abv></Beer><Beer><name>Lager</name><abv>5</abv></Beer></items>",
+		"text/csv":         "name,abv\nStout,4.2\nLager,5\n",
+		"application/yaml": "- abv: 4.2\n  name: Stout\n- abv: 5\n  name: Lager\n",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/beers/", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != 200 || rec.Body.String() != want {
+			t.Errorf("Accept %q: %d %q, want %q", accept, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	var single strings.Builder
+	if err := EncodeXML(&single, Beer{Name: "Stout", ABV: 4.2}); err != nil || single.String() != xml.Header+"<Beer><name>Stout</name><abv>4.2</abv></Beer>" {
+		t.Errorf("EncodeXML = %q, %v", single.String(), err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/beers/", nil)
+	req.Header.Set("Accept", "image/png")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotAcceptable {
+		t.Errorf("status = %d, want 406", rec.Code)
+	}
+
+	paths := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths
+	if want := []string{"application/json", "application/xml", "text/csv", "application/yaml"}; !slices.Equal(paths["/beers/"].Get.Produces, want) {
+		t.Errorf("produces = %v, want %v", paths["/beers/"].Get.Produces, want)
+	}
+	if produces := paths["/beers/{name}"].Get.Produces; !slices.Equal(produces, []string{"application/json"}) {
+		t.Errorf("produces of a JSON handler = %v", produces)
+	}
+
+	// Encoders are only advertised for the responses they can encode
+	if want := []string{"application/json", "application/xml", "application/yaml"}; !slices.Equal(paths["/beers/orders"].Get.Produces, want) {
+		t.Errorf("produces of nested structs = %v, want %v", paths["/beers/orders"].Get.Produces, want)
+	}
+	if want := []string{"application/json", "application/yaml"}; !slices.Equal(paths["/beers/stock"].Get.Produces, want) {
+		t.Errorf("produces of a map = %v, want %v", paths["/beers/stock"].Get.Produces, want)
+	}
+	if err := EncodeCSV(io.Discard, []BeerOrder{{ID: 1}}); err == nil {
+		t.Error("EncodeCSV encoded nested structs")
+	}
+}
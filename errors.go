@@ -1,9 +1,15 @@
 package pf
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"reflect"
+	"slices"
+	"strings"
 )
 
 type httpError int
@@ -56,10 +62,222 @@ var (
 	ErrNetworkAuthenticationRequired error = httpError(511)
 )
 
+// Error is an application error carrying the HTTP status code, a
+// machine-readable code clients may switch on, a message that is safe to show
+// to clients and optional details. The underlying cause is logged for server
+// errors, but only shown to clients if the Error is exposed.
+//
+// The methods of Error return modified copies, so an Error may be declared once
+// and reused across requests.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+
+	// Err is the underlying cause of the error.
+	Err error
+
+	// Exposed reports whether the cause is included in responses.
+	Exposed bool
+}
+
+// NewError returns an Error with the specified status code, code and message.
+func NewError(status int, code, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Errorf returns an Error with the status code (and, for an Error, the code and
+// details) of base and a message formatted like fmt.Errorf. Errors wrapped
+// with %w become the cause of the returned Error and are left out of its
+// message, along with a ": " separating them from the rest of it, so they are
+// only shown to clients if the Error is exposed. If base is not an error
+// defined in this package, the status code is 500 and base is the cause.
+//
+//	return pf.Errorf(pf.ErrNotFound, "order %d doesn't exist", id)
+func Errorf(base error, format string, args ...any) *Error {
+	formatted := fmt.Errorf(format, args...)
+
+	var e Error
+	var appErr *Error
+	var httpErr httpError
+	switch {
+	case errors.As(base, &appErr):
+		e = *appErr
+	case errors.As(base, &httpErr):
+		e.Status = int(httpErr)
+	default:
+		e.Status = http.StatusInternalServerError
+		e.Err = base
+	}
+
+	var wrapped []error
+	if cause := errors.Unwrap(formatted); cause != nil {
+		wrapped = []error{cause}
+		e.Err = cause
+	} else if multi, ok := formatted.(interface{ Unwrap() []error }); ok {
+		wrapped = multi.Unwrap()
+		e.Err = errors.Join(wrapped...)
+	}
+	e.Message = errorMessage(format, args, wrapped)
+
+	return &e
+}
+
+// errorMessage formats the message of Errorf without the errors wrapped
+// with %w. The spaces and colon separating a cause at either end of the
+// message are trimmed with it; other punctuation is kept.
+func errorMessage(format string, args []any, wrapped []error) string {
+	hidden := slices.Clone(args)
+	for i, arg := range hidden {
+		if err, ok := arg.(error); ok && slices.ContainsFunc(wrapped, func(w error) bool { return sameError(err, w) }) {
+			hidden[i] = hiddenCause{err}
+		}
+	}
+
+	msg := fmt.Errorf(format, hidden...).Error()
+	if trimmed, ok := strings.CutSuffix(msg, causeMarker); ok {
+		msg = strings.TrimRight(strings.TrimSuffix(strings.TrimRight(trimmed, " "), ":"), " ")
+	}
+	if trimmed, ok := strings.CutPrefix(msg, causeMarker); ok {
+		msg = strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(trimmed, " "), ":"), " ")
+	}
+	return strings.ReplaceAll(msg, causeMarker, "")
+}
+
+// causeMarker marks the place of the causes in the message formatted by
+// errorMessage.
+const causeMarker = "\x00"
+
+// hiddenCause is an error formatted as causeMarker.
+type hiddenCause struct {
+	error
+}
+
+func (hiddenCause) Format(f fmt.State, verb rune) {
+	io.WriteString(f, causeMarker)
+}
+
+// sameError reports whether a and b are the same error, without panicking on
+// errors of uncomparable types.
+func sameError(a, b error) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta.Comparable() && a == b
+}
+
+// WithDetails returns a copy of e carrying details, which are marshaled into
+// the response.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap returns a copy of e with err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// Expose returns a copy of e whose cause is included in responses. Only expose
+// causes that don't leak internal details.
+func (e *Error) Expose() *Error {
+	c := *e
+	c.Exposed = true
+	return &c
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.status())
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error defined in the package for the status code of e
+// and the cause, so that errors.Is(e, ErrNotFound) holds for a 404 Error.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{httpError(e.status())}
+	}
+	return []error{httpError(e.status()), e.Err}
+}
+
+func (e *Error) status() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+type errorBody struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+	Cause   string `json:"cause,omitempty"`
+}
+
+// MarshalJSON serializes the parts of e that are safe to show to clients.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	body := errorBody{
+		Code:    e.Code,
+		Message: e.Message,
+		Details: e.Details,
+	}
+	if body.Message == "" {
+		body.Message = http.StatusText(e.status())
+	}
+	if e.Exposed && e.Err != nil {
+		body.Cause = e.Err.Error()
+	}
+	return json.Marshal(body)
+}
+
+// log logs e if it is a server error.
+func (e *Error) log() {
+	if e.status() >= 500 {
+		slog.Error("Error in handler", "status", e.status(), "code", e.Code, "err", e.Error())
+	}
+}
+
 // HandleError handles any errors that might occur in handlers and middlewares. For errors defined in the package,
-// HandleError sets the appropriate status code and responds with the standard message. For other errors,
+// HandleError sets the appropriate status code and responds with the standard message. A ValidationError is
+// responded with status code 422 and a JSON list of the failing fields, an Error is responded with its status
+// code and JSON representation, and a Problem is responded with as application/problem+json. For other errors,
 // HandleError logs the error with slog.Error and responds with status code 500 and the standard message.
 func HandleError(w http.ResponseWriter, err error) {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		writeProblem(w, toProblem(problem))
+		return
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(validationErr)
+		return
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		appErr.log()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(appErr.status())
+		json.NewEncoder(w).Encode(appErr)
+		return
+	}
+
 	var httpErr httpError
 	if errors.As(err, &httpErr) {
 		http.Error(w, httpErr.Error(), int(httpErr))
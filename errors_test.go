@@ -0,0 +1,113 @@
+package pf
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var errOrderMissing = NewError(http.StatusNotFound, "order_missing", "order doesn't exist")
+
+func TestHandleAppError(t *testing.T) {
+	dbErr := errors.New("db: connection refused")
+
+	tests := []struct {
+		err    error
+		status int
+		body   errorBody
+	}{
+		{Errorf(ErrNotFound, "order %d", 42), 404, errorBody{Message: "order 42"}},
+		{errOrderMissing.WithDetails(map[string]int{"id": 42}), 404, errorBody{Code: "order_missing", Message: "order doesn't exist", Details: map[string]any{"id": 42.0}}},
+		{Errorf(errOrderMissing, "order %d", 7), 404, errorBody{Code: "order_missing", Message: "order 7"}},
+		{NewError(http.StatusServiceUnavailable, "db", "try again later").Wrap(dbErr), 503, errorBody{Code: "db", Message: "try again later"}},
+		{NewError(http.StatusBadGateway, "", "upstream failed").Wrap(dbErr).Expose(), 502, errorBody{Message: "upstream failed", Cause: dbErr.Error()}},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		HandleError(rec, test.err)
+
+		if rec.Code != test.status {
+			t.Errorf("%v: status = %d, want %d", test.err, rec.Code, test.status)
+		}
+
+		var body errorBody
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := json.Marshal(body); string(got) != string(must(json.Marshal(test.body))) {
+			t.Errorf("%v: body = %s", test.err, got)
+		}
+	}
+
+	if errOrderMissing.Details != nil {
+		t.Error("WithDetails modified the original error")
+	}
+
+	err := Errorf(ErrInternalServerError, "saving order: %w", dbErr)
+	if !errors.Is(err, dbErr) || !errors.Is(err, ErrInternalServerError) {
+		t.Errorf("%v does not wrap its cause and status", err)
+	}
+	if msg := err.Error(); strings.Count(msg, dbErr.Error()) != 1 {
+		t.Errorf("Error() = %q, want the cause once", msg)
+	}
+}
+
+func TestErrorfHidesCause(t *testing.T) {
+	secret := errors.New("db: password=hunter2")
+
+	rec := httptest.NewRecorder()
+	HandleError(rec, Errorf(ErrInternalServerError, "saving order %d: %w", 42, secret))
+
+	if strings.Contains(rec.Body.String(), "hunter2") {
+		t.Errorf("body = %s, leaks the wrapped error", rec.Body)
+	}
+	var body errorBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Message != "saving order 42" {
+		t.Errorf("message = %q, want %q", body.Message, "saving order 42")
+	}
+}
+
+func TestErrorfMessage(t *testing.T) {
+	dbErr := errors.New("db: connection refused")
+	tests := []struct {
+		err     *Error
+		message string
+		cause   error
+	}{
+		{Errorf(ErrNotFound, "order %d doesn't exist.", 42), "order 42 doesn't exist.", nil},
+		{Errorf(ErrConflict, "retry -"), "retry -", nil},
+		{Errorf(ErrConflict, "order %q: %v", "a:b", "taken;"), `order "a:b": taken;`, nil},
+		{Errorf(ErrInternalServerError, "saving order %d: %w", 42, dbErr), "saving order 42", dbErr},
+		{Errorf(ErrInternalServerError, "%[2]w while saving order %[1]d", 42, dbErr), "while saving order 42", dbErr},
+		{Errorf(ErrInternalServerError, "%[1]w: saving order %[2]d: %+[1]w", dbErr, 42), "saving order 42", dbErr},
+		{Errorf(ErrBadGateway, "%-10w", dbErr), "", dbErr},
+	}
+
+	for _, test := range tests {
+		if test.err.Message != test.message {
+			t.Errorf("message = %q, want %q", test.err.Message, test.message)
+		}
+		if !errors.Is(test.err.Err, test.cause) || (test.cause == nil) != (test.err.Err == nil) {
+			t.Errorf("%q: cause = %v, want %v", test.message, test.err.Err, test.cause)
+		}
+	}
+
+	body := string(must(json.Marshal(Errorf(ErrBadGateway, "calling upstream: %w", dbErr).Expose())))
+	if body != `{"message":"calling upstream","cause":"db: connection refused"}` {
+		t.Errorf("exposed body = %s", body)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
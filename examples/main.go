@@ -41,7 +41,7 @@ func main() {
 	pf.Route(r, "/wat", func(r *pf.Router) {
 		// Add metadata for Swagger
 		pf.Post(r, "/uploadbeer", LogHandler, pf.WithSummary("This shi logs"))
-	})
+	}, pf.WithTags("wat"))
 
 	// Create a Swagger endpoint
 	pf.AddSwagger(r, "/swagger", &pf.SwaggerInfo{
@@ -0,0 +1,165 @@
+package pf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// jsonField is a struct field encoded by encoding/json.
+type jsonField struct {
+	field reflect.StructField
+	name  string
+
+	tagged bool
+
+	// optional is set for fields left out of some objects: omitempty fields
+	// and fields promoted from embedded pointers, which may be nil.
+	optional bool
+
+	// quoted is set for ",string" fields, encoded as JSON strings.
+	quoted bool
+
+	depth int
+}
+
+// jsonFields returns the fields of typ encoded by encoding/json, following
+// its rules: unexported and "-" fields are skipped, and the fields of
+// untagged embedded structs are promoted. Of several fields with the same
+// name, the least nested one wins, then the only tagged one; otherwise none
+// is encoded.
+func jsonFields(typ reflect.Type) []jsonField {
+	var candidates []jsonField
+	visited := make(map[reflect.Type]bool)
+
+	type embedded struct {
+		typ reflect.Type
+		ptr bool
+	}
+
+	for level, depth := []embedded{{typ: typ}}, 0; len(level) > 0; depth++ {
+		var next []embedded
+		for _, e := range level {
+			// Types embedded twice at the same depth are walked twice, so
+			// that their fields conflict and are dropped like encoding/json
+			t := e.typ
+			if visited[t] {
+				continue
+			}
+
+			for i := 0; i < t.NumField(); i++ {
+				sf := t.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if sf.Anonymous {
+					// Unexported embedded structs still promote their fields
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, ptr: e.ptr || sf.Type.Kind() == reflect.Pointer})
+					continue
+				}
+
+				f := jsonField{
+					field:    sf,
+					name:     name,
+					tagged:   name != "",
+					optional: e.ptr || hasOption(opts, "omitempty") || hasOption(opts, "omitzero"),
+					depth:    depth,
+				}
+				if !f.tagged {
+					f.name = sf.Name
+				}
+				if hasOption(opts, "string") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						f.quoted = true
+					}
+				}
+				candidates = append(candidates, f)
+			}
+		}
+
+		for _, e := range level {
+			visited[e.typ] = true
+		}
+		level = next
+	}
+
+	byName := make(map[string][]int)
+	for i, f := range candidates {
+		byName[f.name] = append(byName[f.name], i)
+	}
+
+	var fields []jsonField
+	for i, f := range candidates {
+		if dominant, ok := dominantField(candidates, byName[f.name]); ok && dominant == i {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// dominantField returns the index of the field encoded among the candidates
+// at indexes, which share a name.
+func dominantField(candidates []jsonField, indexes []int) (int, bool) {
+	depth := candidates[indexes[0]].depth
+	for _, i := range indexes {
+		depth = min(depth, candidates[i].depth)
+	}
+
+	var shallowest, tagged []int
+	for _, i := range indexes {
+		if candidates[i].depth != depth {
+			continue
+		}
+		shallowest = append(shallowest, i)
+		if candidates[i].tagged {
+			tagged = append(tagged, i)
+		}
+	}
+
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	default:
+		return 0, false
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonName returns the name of field in JSON objects.
+func jsonName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,153 @@
+package pf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+type EncodedBase struct {
+	ID     int `json:"id"`
+	Name   string
+	Shared string `json:"shared"`
+}
+
+type EncodedMeta struct {
+	Shared string `json:"shared"`
+	Note   string
+
+	// Conflicts with EncodedBase.ID, so neither is encoded
+	ID int `json:"id"`
+}
+
+type Encoded struct {
+	EncodedBase
+	*EncodedMeta
+	Inner  EncodedBase `json:"inner"`
+	Name   string      `json:"name"`
+	Count  int64       `json:"count,string"`
+	Flag   bool        `json:",string"`
+	Skip   string      `json:"-"`
+	Dash   string      `json:"-,"`
+	Opt    *string     `json:"opt"`
+	Empty  string      `json:"empty,omitempty"`
+	Values []float64   `json:"values"`
+	hidden int
+}
+
+func TestJSONFields(t *testing.T) {
+	r := NewRouter()
+	Get(r, "/encoded", func(w ResponseWriter[Encoded], r *Request[struct{}]) error {
+		return nil
+	})
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+
+	def := s.Definitions["Encoded"]
+	var names []string
+	for name := range def.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"-", "Flag", "Name", "Note", "count", "empty", "inner", "name", "opt", "values"}
+	if !slices.Equal(names, want) {
+		t.Errorf("properties = %v, want %v", names, want)
+	}
+	slices.Sort(def.Required)
+	if want := []string{"-", "Flag", "Name", "count", "inner", "name", "values"}; !slices.Equal(def.Required, want) {
+		t.Errorf("required = %v, want %v", def.Required, want)
+	}
+
+	opt := "set"
+	values := []Encoded{
+		{},
+		{
+			EncodedBase: EncodedBase{ID: 1, Name: "base", Shared: "dropped"},
+			EncodedMeta: &EncodedMeta{Shared: "dropped", Note: "note", ID: 2},
+			Inner:       EncodedBase{ID: 3},
+			Count:       math.MaxInt64,
+			Flag:        true,
+			Opt:         &opt,
+			Empty:       "full",
+			Values:      []float64{1.5},
+			hidden:      1,
+		},
+	}
+	for _, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range conform(s, s.Definitions["Encoded"], decoded, "") {
+			t.Errorf("%s: %v", data, err)
+		}
+	}
+}
+
+// conform returns the mismatches between v, decoded from JSON, and schema.
+func conform(s *spec.Swagger, schema spec.Schema, v any, path string) []error {
+	if ref := schema.Ref.String(); ref != "" {
+		schema = s.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+	}
+	if v == nil {
+		if schema.Extensions["x-nullable"] == true || len(schema.Type) == 0 || schema.Type[0] == "array" || schema.Type[0] == "object" {
+			return nil
+		}
+		return []error{fmt.Errorf("%s: unexpected null", path)}
+	}
+
+	var errs []error
+	for _, sub := range schema.AllOf {
+		errs = append(errs, conform(s, sub, v, path)...)
+	}
+	if len(schema.Type) == 0 {
+		return errs
+	}
+
+	ok := true
+	switch schema.Type[0] {
+	case "object":
+		obj, isObj := v.(map[string]any)
+		ok = isObj
+		for name, value := range obj {
+			prop, found := schema.Properties[name]
+			if !found {
+				errs = append(errs, fmt.Errorf("%s: undocumented property %q", path, name))
+				continue
+			}
+			errs = append(errs, conform(s, prop, value, path+"."+name)...)
+		}
+		for _, name := range schema.Required {
+			if _, found := obj[name]; isObj && !found {
+				errs = append(errs, fmt.Errorf("%s: missing required property %q", path, name))
+			}
+		}
+	case "array":
+		arr, isArr := v.([]any)
+		ok = isArr
+		for i, item := range arr {
+			errs = append(errs, conform(s, *schema.Items.Schema, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		_, ok = v.(string)
+	case "integer":
+		n, isNum := v.(float64)
+		ok = isNum && n == math.Trunc(n)
+	case "number":
+		_, ok = v.(float64)
+	case "boolean":
+		_, ok = v.(bool)
+	}
+	if !ok {
+		errs = append(errs, fmt.Errorf("%s: %v is not %s", path, v, schema.Type[0]))
+	}
+	return errs
+}
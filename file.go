@@ -0,0 +1,184 @@
+package pf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-openapi/spec"
+)
+
+// fileType is the response type of handlers serving files, documented as
+// binary responses. Handlers may declare it as their response type, e.g.
+// Handler[Req, fs.File], to document the responses of ServeFile and
+// ServeContent.
+var fileType = reflect.TypeFor[fs.File]()
+
+// FileOptions describes the content served by ServeContent.
+type FileOptions struct {
+	// Name is the name of the file, used to detect the content type and in
+	// the Content-Disposition header.
+	Name string
+
+	// ModTime is the modification time of the content, used for
+	// If-Modified-Since requests if not zero.
+	ModTime time.Time
+
+	// ETag is the entity tag of the content, matched against If-None-Match,
+	// If-Match and If-Range requests if not empty.
+	ETag string
+
+	// ContentType is detected from the name and content if empty.
+	ContentType string
+
+	// Disposition is "inline" to have browsers display the content, or
+	// "attachment" to have them download it as a file named Name. No
+	// Content-Disposition header is sent if empty.
+	Disposition string
+}
+
+// ServeContent sends content as described by opts. Range requests are
+// served with 206 Partial Content, and conditional requests with 304 Not
+// Modified or 412 Precondition Failed.
+func (w *ResponseWriter[T]) ServeContent(content io.ReadSeeker, opts FileOptions) error {
+	if w.req == nil {
+		return errors.New("pf: ServeContent called without a request")
+	}
+
+	h := w.Header()
+	if opts.ContentType != "" {
+		h.Set("Content-Type", opts.ContentType)
+	}
+	if opts.ETag != "" {
+		h.Set("ETag", opts.ETag)
+	}
+	if opts.Disposition != "" {
+		h.Set("Content-Disposition", contentDisposition(opts.Disposition, opts.Name))
+	}
+
+	http.ServeContent(w.ResponseWriter, w.req, opts.Name, opts.ModTime, content)
+	return nil
+}
+
+// ServeFile sends the content of f, which must implement io.Seeker like the
+// files of os.DirFS and embed.FS, named and dated by its Stat, with the
+// Content-Disposition disposition as in FileOptions. Its ETag is derived from
+// its size and modification time. f is not closed.
+func (w *ResponseWriter[T]) ServeFile(f fs.File, disposition string) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return Errorf(ErrNotFound, "%s is a directory", info.Name())
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		return fmt.Errorf("pf: file %s is not seekable", info.Name())
+	}
+
+	return w.ServeContent(content, FileOptions{
+		Name:        info.Name(),
+		ModTime:     info.ModTime(),
+		ETag:        fileETag(info),
+		Disposition: disposition,
+	})
+}
+
+// fileETag returns an entity tag of the file described by info. It is strong
+// so that it matches If-Match and If-Range requests.
+func fileETag(info fs.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// contentDisposition returns a Content-Disposition header for the file name,
+// stripped of directories, encoding non-ASCII names as RFC 2231 parameters.
+func contentDisposition(disposition, name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return disposition
+	}
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": name}); v != "" {
+		return v
+	}
+	return disposition
+}
+
+// Static adds GET and HEAD routes serving the files of fsys under path, such
+// as /assets/css/site.css for the file css/site.css of fsys with path
+// "/assets". Directories are served by their index.html. Missing files are
+// responded to with the error handler of r. Both operations are documented,
+// like routes added with Get and Head, as a binary response.
+func Static(r *Router, path string, fsys fs.FS, props ...HandlerProperty) {
+	pattern := strings.TrimSuffix(path, "/") + "/*"
+	h := func(w ResponseWriter[fs.File], req *Request[struct{}]) error {
+		return w.serveFS(fsys, chi.URLParam(req.Request, "*"))
+	}
+	handler, signature := Handler[struct{}, fs.File](h).wrap(r, props)
+	r.mux.Method(http.MethodGet, pattern, handler)
+	r.mux.Method(http.MethodHead, pattern, handler)
+	r.signatures.add(pattern, http.MethodGet, signature)
+	r.signatures.add(pattern, http.MethodHead, signature)
+}
+
+// serveFS serves the file name of fsys.
+func (w *ResponseWriter[T]) serveFS(fsys fs.FS, name string) error {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fsError(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fsError(err)
+	}
+	if info.IsDir() {
+		index, err := fsys.Open(path.Join(name, "index.html"))
+		if err != nil {
+			return fsError(err)
+		}
+		defer index.Close()
+		f = index
+	}
+
+	return w.ServeFile(f, "")
+}
+
+// fsError converts an error opening a file into an HTTP error.
+func fsError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrForbidden
+	default:
+		return err
+	}
+}
+
+// describeFile documents the binary response of op, including partial and
+// not modified responses.
+func describeFile(op *spec.Operation) {
+	op.Produces = []string{"application/octet-stream"}
+	op.RespondsWith(http.StatusOK, spec.NewResponse().
+		WithDescription(http.StatusText(http.StatusOK)).
+		WithSchema(new(spec.Schema).Typed("file", "")))
+	op.RespondsWith(http.StatusPartialContent, spec.NewResponse().
+		WithDescription("Range of the content").
+		WithSchema(new(spec.Schema).Typed("file", "")))
+	op.RespondsWith(http.StatusNotModified, spec.NewResponse().WithDescription(http.StatusText(http.StatusNotModified)))
+}
@@ -0,0 +1,121 @@
+package pf
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/site.css": {Data: []byte("body { color: red }"), ModTime: time.Unix(1e9, 0)},
+		"index.html":   {Data: []byte("<h1>Beer</h1>")},
+	}
+	r := NewRouter()
+	Static(r, "/assets/", fsys)
+
+	tests := []struct {
+		method, path string
+		header       map[string]string
+		status       int
+		body         string
+	}{
+		{"GET", "/assets/css/site.css", nil, 200, "body { color: red }"},
+		{"HEAD", "/assets/css/site.css", nil, 200, ""},
+		{"GET", "/assets/css/site.css", map[string]string{"Range": "bytes=0-3"}, 206, "body"},
+		{"GET", "/assets/css/site.css", map[string]string{"If-Modified-Since": time.Unix(2e9, 0).UTC().Format(http.TimeFormat)}, 304, ""},
+		{"GET", "/assets/", nil, 200, "<h1>Beer</h1>"},
+		{"GET", "/assets/css/../../index.html", nil, 200, "<h1>Beer</h1>"},
+		{"GET", "/assets/missing.js", nil, 404, "Not Found"},
+		{"GET", "/assets/css", nil, 404, ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != test.status || !strings.Contains(rec.Body.String(), test.body) {
+			t.Errorf("%s %s %v: %d %q, want %d %q", test.method, test.path, test.header, rec.Code, rec.Body.String(), test.status, test.body)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/css/site.css", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %d", rec.Code)
+	}
+
+	etag := req.Header.Get("If-None-Match")
+	conditions := []struct {
+		header map[string]string
+		status int
+		body   string
+	}{
+		{map[string]string{"If-Range": etag, "Range": "bytes=0-3"}, 206, "body"},
+		{map[string]string{"If-Range": `"stale"`, "Range": "bytes=0-3"}, 200, "body { color: red }"},
+		{map[string]string{"If-Match": etag}, 200, "body { color: red }"},
+		{map[string]string{"If-Match": `"stale"`}, 412, ""},
+	}
+	for _, test := range conditions {
+		req := httptest.NewRequest(http.MethodGet, "/assets/css/site.css", nil)
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != test.status || rec.Body.String() != test.body {
+			t.Errorf("%v: %d %q, want %d %q", test.header, rec.Code, rec.Body.String(), test.status, test.body)
+		}
+	}
+
+	item := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/assets/{*}"]
+	for _, op := range []*spec.Operation{item.Get, item.Head} {
+		if op == nil || op.Produces[0] != "application/octet-stream" || !op.Responses.StatusCodeResponses[206].Schema.Type.Contains("file") {
+			t.Errorf("operation = %+v", op)
+		}
+	}
+}
+
+func TestServeFile(t *testing.T) {
+	fsys := fstest.MapFS{"report.csv": {Data: []byte("a,b\n")}}
+	r := NewRouter()
+	Get(r, "/report", func(w ResponseWriter[fs.File], r *Request[struct{}]) error {
+		f, err := fsys.Open("report.csv")
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return w.ServeFile(f, "attachment")
+	})
+	Get(r, "/export", func(w ResponseWriter[fs.File], r *Request[struct{}]) error {
+		return w.ServeContent(strings.NewReader("x"), FileOptions{Name: "../Überblick.txt", Disposition: "attachment"})
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Body.String() != "a,b\n" || rec.Header().Get("Content-Disposition") != "attachment; filename=report.csv" {
+		t.Errorf("report = %q, headers = %v", rec.Body.String(), rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if want := "attachment; filename*=utf-8''%C3%9Cberblick.txt"; rec.Header().Get("Content-Disposition") != want {
+		t.Errorf("Content-Disposition = %q, want %q", rec.Header().Get("Content-Disposition"), want)
+	}
+}
@@ -1,41 +1,177 @@
 package pf
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"reflect"
+	"runtime/debug"
+	"sync"
 )
 
 // Handler represents an HTTP callback. Handler takes in a parsed Request
 // (optionally, to do nothing set Req to struct{}), as well as a
 // ResponseWriter that may marshal the response. Request and ResponseWriter
 // contain the underlying http.Request and ResponseWriter from [net/http].
+//
+// Before the handler is called, the request body is checked against the
+// `validate:"..."` tags of its fields and, if it implements Validator, its
+// Validate method. The tag is a comma-separated list of rules: required, min=N,
+// max=N (bounds of numbers or lengths of strings, slices and maps), email,
+// oneof=a b c and regex=EXPR, which must come last. Requests that fail
+// validation are responded to with a ValidationError.
 type Handler[Req, Res any] func(w ResponseWriter[Res], r *Request[Req]) error
 
+// ErrorHandler responds to an error that occurred while handling r.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
 type handlerSignature struct {
+	router  *Router
 	reqType reflect.Type
 	resType reflect.Type
 
+	// events is set for SSE handlers, whose resType is the type of events.
+	events bool
+
+	// webSocket is set for WebSocket handlers, whose reqType is the type of the
+	// handshake request and resType and received are the types of the
+	// messages sent and received.
+	webSocket bool
+	received  reflect.Type
+
 	props []HandlerProperty
 }
 
-func (h Handler[Req, Res]) wrap(props []HandlerProperty) (http.HandlerFunc, *handlerSignature) {
+func (h Handler[Req, Res]) wrap(router *Router, props []HandlerProperty) (http.HandlerFunc, *handlerSignature) {
+	parse := requestParser[Req](router)
+
+	// Group props are only known once the router is mounted
+	responses := sync.OnceValue(func() map[int]reflect.Type {
+		return declaredResponses(append(router.groupProps(), props...))
+	})
+	encoders := sync.OnceValue(router.mediaEncoders)
+
 	handler := func(w http.ResponseWriter, r *http.Request) {
-		req, err := parseRequest[Req](r)
+		sw := &startedWriter{ResponseWriter: w}
+		defer recoverPanic(func(err error) { sw.fail(router, r, err) })
+
+		req, err := parse(r)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to parse request body: %v", err), http.StatusBadRequest)
+			router.handleError(w, r, err)
 			return
 		}
 
-		err = h(ResponseWriter[Res]{w}, req)
-		if err != nil {
-			HandleError(w, err)
+		err = h(ResponseWriter[Res]{
+			ResponseWriter: sw,
+			responses:      responses(),
+			accept:         r.Header.Get("Accept"),
+			encoders:       encoders,
+			ctx:            r.Context(),
+			req:            r,
+		}, req)
+
+		// Clients which disconnected from streams can't be responded to
+		if err != nil && !(errors.Is(err, context.Canceled) && r.Context().Err() != nil) {
+			sw.fail(router, r, err)
 		}
 	}
 
 	return handler, &handlerSignature{
+		router:  router,
 		reqType: reflect.TypeFor[Req](),
 		resType: reflect.TypeFor[Res](),
 		props:   props,
 	}
 }
+
+// requestParser returns the function parsing the requests of the handlers of
+// router taking Req, and validating their body. Its errors are responded to
+// with the error handler. Malformed validation tags panic on creation, so on
+// registration of the handlers.
+func requestParser[Req any](router *Router) func(r *http.Request) (*Request[Req], error) {
+	getValidator(reflect.TypeFor[Req]())
+	decoders := sync.OnceValue(router.mediaDecoders)
+
+	return func(r *http.Request) (*Request[Req], error) {
+		req, err := parseRequest[Req](r, decoders())
+		if err != nil {
+			return nil, parseError(err)
+		}
+		if err := validate(&req.Body); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
+// recoverPanic, deferred by handlers before running any code of the request,
+// passes panics to fail as errors. http.ErrAbortHandler is panicked again.
+func recoverPanic(fail func(err error)) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if p == http.ErrAbortHandler {
+		panic(p)
+	}
+	fail(fmt.Errorf("panic: %v\n%s", p, debug.Stack()))
+}
+
+// startedWriter records whether the response was started, after which errors
+// can't be responded to.
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) WriteHeader(status int) {
+	// Informational responses precede the actual response
+	if status >= 200 {
+		w.started = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *startedWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *startedWriter) Flush() {
+	w.FlushError()
+}
+
+// FlushError flushes the underlying writer, for http.ResponseController.
+func (w *startedWriter) FlushError() error {
+	w.started = true
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *startedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// fail handles err with the error handler of router if the response wasn't
+// started, or logs it otherwise.
+func (w *startedWriter) fail(router *Router, r *http.Request, err error) {
+	if w.started {
+		slog.Error("Error after the response was started", "method", r.Method, "path", r.URL.Path, "err", err.Error())
+		return
+	}
+	router.handleError(w.ResponseWriter, r, err)
+}
+
+// parseError wraps a request parsing failure into a 400 Error exposing the
+// reason to the client.
+func parseError(err error) *Error {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		return NewError(http.StatusUnsupportedMediaType, "unsupported_media_type", "Unsupported request body media type").Wrap(err).Expose()
+	}
+	if errors.As(err, new(*BindError)) {
+		return NewError(http.StatusBadRequest, "invalid_parameter", "Invalid request parameter").Wrap(err).Expose()
+	}
+	return NewError(http.StatusBadRequest, "invalid_body", "Invalid request body").Wrap(err).Expose()
+}
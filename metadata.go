@@ -0,0 +1,155 @@
+package pf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Describer is implemented by types describing themselves in the spec.
+type Describer interface {
+	Describe() string
+}
+
+// JSONSchemer is implemented by types providing their own schema, which
+// replaces the one generated from the type.
+type JSONSchemer interface {
+	JSONSchema() spec.Schema
+}
+
+// implements returns typ's value as I if typ or a pointer to it implements I.
+func implements[I any](typ reflect.Type) (I, bool) {
+	i, ok := reflect.New(typ).Interface().(I)
+	return i, ok
+}
+
+// applyTags adds the metadata given by the tags of field to its schema:
+//
+//	doc:"..."          description
+//	example:"..."      example value
+//	default:"..."      default value
+//	format:"uuid"      format
+//	enum:"a,b"         allowed values, of the items for slices
+//	deprecated:"true"  deprecated
+//	readOnly:"true"    only sent in responses
+//	writeOnly:"true"   only sent in requests
+//
+// Values are parsed according to the field's type, with JSON for composite
+// types.
+func applyTags(prop spec.Schema, field reflect.StructField) spec.Schema {
+	tags := field.Tag
+
+	var meta spec.Schema
+	meta.Description = tags.Get("doc")
+	meta.Format = tags.Get("format")
+	if v, ok := tags.Lookup("example"); ok {
+		meta.Example = tagValue(v, field.Type)
+	}
+	if v, ok := tags.Lookup("default"); ok {
+		meta.Default = tagValue(v, field.Type)
+	}
+	meta.ReadOnly = tagBool(tags, "readOnly")
+	if tagBool(tags, "writeOnly") {
+		meta.AddExtension("x-writeonly", true)
+	}
+	if tagBool(tags, "deprecated") {
+		meta.AddExtension("x-deprecated", true)
+	}
+
+	if prop.Ref.String() != "" {
+		if reflect.ValueOf(meta).IsZero() {
+			return prop
+		}
+		// Siblings of $ref are ignored, so the reference is wrapped
+		meta.AllOf = []spec.Schema{{SchemaProps: spec.SchemaProps{Ref: prop.Ref}}}
+		return meta
+	}
+
+	if meta.Description != "" {
+		prop.Description = meta.Description
+	}
+	if meta.Format != "" {
+		prop.Format = meta.Format
+	}
+	if meta.Example != nil {
+		prop.Example = meta.Example
+	}
+	if meta.Default != nil {
+		prop.Default = meta.Default
+	}
+	prop.ReadOnly = prop.ReadOnly || meta.ReadOnly
+	for k, v := range meta.Extensions {
+		prop.AddExtension(k, v)
+	}
+
+	if v, ok := tags.Lookup("enum"); ok {
+		typ := derefType(field.Type)
+		if (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && prop.Items != nil && prop.Items.Schema != nil {
+			prop.Items.Schema.Enum = tagValues(v, typ.Elem())
+		} else {
+			prop.Enum = tagValues(v, typ)
+		}
+	}
+
+	return prop
+}
+
+// applyParamTags adds the metadata given by the doc, format, default and enum
+// tags of field to param.
+func applyParamTags(param *spec.Parameter, field reflect.StructField, typ reflect.Type) {
+	tags := field.Tag
+
+	if v := tags.Get("doc"); v != "" {
+		param.Description = v
+	}
+	if v := tags.Get("format"); v != "" {
+		param.Format = v
+	}
+	if v, ok := tags.Lookup("default"); ok {
+		param.Default = tagValue(v, typ)
+	}
+	if v, ok := tags.Lookup("enum"); ok {
+		if param.Items != nil {
+			param.Items.Enum = tagValues(v, derefType(typ).Elem())
+		} else {
+			param.Enum = tagValues(v, typ)
+		}
+	}
+}
+
+func derefType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func tagBool(tags reflect.StructTag, key string) bool {
+	b, _ := strconv.ParseBool(tags.Get(key))
+	return b
+}
+
+// tagValue converts the tag value s to a value of typ.
+func tagValue(s string, typ reflect.Type) any {
+	typ = derefType(typ)
+
+	switch typ.Kind() {
+	case reflect.String:
+		return s
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err == nil {
+			return v
+		}
+		return s
+	}
+	return enumValues([]string{s}, typ)[0]
+}
+
+// tagValues converts the comma-separated tag value s to values of typ.
+func tagValues(s string, typ reflect.Type) []any {
+	return enumValues(strings.Split(s, ","), derefType(typ))
+}
@@ -0,0 +1,77 @@
+package pf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+type UUID string
+
+func (UUID) JSONSchema() spec.Schema {
+	return *spec.StrFmtProperty("uuid")
+}
+
+type Article struct {
+	ID      UUID     `json:"id" readOnly:"true"`
+	Title   string   `json:"title" doc:"The headline" example:"Hello"`
+	Status  string   `json:"status" enum:"draft,published" default:"draft"`
+	Tags    []string `json:"tags" enum:"go,web"`
+	Views   int      `json:"views" example:"42" deprecated:"true"`
+	Secret  string   `json:"secret" writeOnly:"true"`
+	Author  *Author  `json:"author" doc:"Who wrote it"`
+	Version int      `query:"version" doc:"API version" enum:"1,2"`
+}
+
+func (Article) Describe() string { return "A blog post" }
+
+func TestSchemaMetadata(t *testing.T) {
+	r := NewRouter()
+	Post(r, "/articles", func(w ResponseWriter[Article], r *Request[Article]) error {
+		return nil
+	})
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+	def := s.Definitions["Article"]
+	if def.Description != "A blog post" {
+		t.Errorf("description = %q", def.Description)
+	}
+
+	props := def.Properties
+	if p := props["id"]; p.Format != "uuid" || !p.ReadOnly {
+		t.Errorf("id = %+v", p.SchemaProps)
+	}
+	if p := props["title"]; p.Description != "The headline" || p.Example != "Hello" {
+		t.Errorf("title = %+v, example = %v", p.SchemaProps, p.Example)
+	}
+	if p := props["status"]; !reflect.DeepEqual(p.Enum, []any{"draft", "published"}) || p.Default != "draft" {
+		t.Errorf("status = %+v", p.SchemaProps)
+	}
+	if p := props["tags"]; len(p.Items.Schema.Enum) != 2 {
+		t.Errorf("tags = %+v", p.Items.Schema.SchemaProps)
+	}
+	if p := props["views"]; p.Example != int64(42) || p.Extensions["x-deprecated"] != true {
+		t.Errorf("views example = %#v, extensions = %v", p.Example, p.Extensions)
+	}
+	if p := props["author"]; p.Description != "Who wrote it" || len(p.AllOf) != 1 || p.AllOf[0].Ref.String() != "#/definitions/Author" {
+		t.Errorf("author = %+v", p.SchemaProps)
+	}
+
+	param := s.Paths.Paths["/articles"].Post.Parameters[0]
+	if param.Description != "API version" || len(param.Enum) != 2 {
+		t.Errorf("version = %+v", param.ParamProps)
+	}
+
+	doc := must(generateOpenAPI(r, new(SwaggerInfo), nil))
+	props31 := doc.Components.Schemas["Article"]["properties"].(map[string]any)
+	if p := props31["secret"].(map[string]any); p["writeOnly"] != true {
+		t.Errorf("secret = %v", p)
+	}
+	if p := props31["views"].(map[string]any); p["deprecated"] != true {
+		t.Errorf("views = %v", p)
+	}
+	if p := props31["author"].(map[string]any); p["anyOf"] == nil || p["description"] != "Who wrote it" {
+		t.Errorf("author = %v", p)
+	}
+}
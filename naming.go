@@ -0,0 +1,246 @@
+package pf
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-openapi/spec"
+)
+
+// SchemaNamer is implemented by types choosing the name of their definition
+// in the spec.
+type SchemaNamer interface {
+	SchemaName() string
+}
+
+// SetSchemaNamer makes the specs of r name the definition of each type with
+// namer, unless it returns "". Sub-routers inherit the namer of their parent.
+// Names chosen by namer take precedence over SchemaName methods.
+func SetSchemaNamer(r *Router, namer func(reflect.Type) string) {
+	r.schemaNamer = namer
+}
+
+// namer returns the closest schema namer starting at r, or nil if
+// there is none.
+func (r *Router) namer() func(reflect.Type) string {
+	for ; r != nil; r = r.parent {
+		if r.schemaNamer != nil {
+			return r.schemaNamer
+		}
+	}
+	return nil
+}
+
+// structMap stores the definitions of the named types found while
+// generating a spec. Until every type is known and can be named, definitions
+// are referenced by placeholder keys.
+type structMap struct {
+	schemas map[reflect.Type]spec.Schema
+	keys    map[reflect.Type]string
+
+	warnings *specWarnings
+}
+
+func newStructMap(warnings *specWarnings) *structMap {
+	return &structMap{
+		schemas:  make(map[reflect.Type]spec.Schema),
+		keys:     make(map[reflect.Type]string),
+		warnings: warnings,
+	}
+}
+
+// ref returns a reference to the definition of typ.
+func (m *structMap) ref(typ reflect.Type) spec.Schema {
+	key, ok := m.keys[typ]
+	if !ok {
+		key = "pf:" + strconv.Itoa(len(m.keys))
+		m.keys[typ] = key
+	}
+
+	return spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Ref: spec.MustCreateRef("#/definitions/" + key),
+		},
+	}
+}
+
+// name names the definitions of m. Types are named by namer, their
+// SchemaName method or their sanitized Go name, in that order. Default names
+// shared by several types are qualified with the package name, and an error
+// is returned if names still collide.
+func (m *structMap) name(namer func(reflect.Type) string) (map[reflect.Type]string, error) {
+	names := make(map[reflect.Type]string, len(m.schemas))
+	custom := make(map[reflect.Type]bool)
+	for typ := range m.schemas {
+		if namer != nil {
+			names[typ] = namer(typ)
+		}
+		if n, ok := reflect.New(typ).Interface().(SchemaNamer); ok && names[typ] == "" {
+			names[typ] = n.SchemaName()
+		}
+		if names[typ] != "" {
+			custom[typ] = true
+		} else {
+			names[typ] = typeName(typ)
+		}
+	}
+
+	for _, types := range groupByName(names) {
+		if len(types) < 2 {
+			continue
+		}
+		for _, typ := range types {
+			if !custom[typ] {
+				names[typ] = path.Base(typ.PkgPath()) + "." + names[typ]
+			}
+		}
+	}
+
+	for name, types := range groupByName(names) {
+		if len(types) < 2 {
+			continue
+		}
+		list := make([]string, len(types))
+		for i, typ := range types {
+			list[i] = typ.PkgPath() + "." + typ.Name()
+		}
+		slices.Sort(list)
+		return nil, fmt.Errorf("swagger: types %s share the definition name %q", strings.Join(list, ", "), name)
+	}
+
+	return names, nil
+}
+
+func groupByName(names map[reflect.Type]string) map[string][]reflect.Type {
+	groups := make(map[string][]reflect.Type)
+	for typ, name := range names {
+		groups[name] = append(groups[name], typ)
+	}
+	return groups
+}
+
+var (
+	importPath = regexp.MustCompile(`[\w.\-~]*/`)
+	qualifier  = regexp.MustCompile(`\w+\.`)
+)
+
+// typeName returns the name of typ usable in a $ref, turning the type
+// arguments of generic types into words, so Page[[]users.User] becomes
+// PageArrayUser.
+func typeName(typ reflect.Type) string {
+	name := typ.Name()
+	name = importPath.ReplaceAllString(name, "")
+	name = qualifier.ReplaceAllString(name, "")
+	name = strings.NewReplacer("[]", " Array ", "map[", " Map ", "*", "").Replace(name)
+
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	return b.String()
+}
+
+// resolveRefs replaces the placeholder keys of the references in s with the
+// names of their definitions.
+func (m *structMap) resolveRefs(s *spec.Swagger, names map[reflect.Type]string) {
+	refs := make(map[string]string, len(m.keys))
+	for typ, key := range m.keys {
+		refs["#/definitions/"+key] = "#/definitions/" + names[typ]
+	}
+
+	walkSchemas(s, func(schema *spec.Schema) {
+		if ref, ok := refs[schema.Ref.String()]; ok {
+			schema.Ref = spec.MustCreateRef(ref)
+		}
+	})
+}
+
+// walkSchemas calls fn with every schema of s, including the nested ones,
+// before the schemas nested in it.
+func walkSchemas(s *spec.Swagger, fn func(schema *spec.Schema)) {
+	var walk func(schema *spec.Schema)
+	walk = func(schema *spec.Schema) {
+		if schema == nil {
+			return
+		}
+		fn(schema)
+
+		for name, prop := range schema.Properties {
+			walk(&prop)
+			schema.Properties[name] = prop
+		}
+		if schema.Items != nil {
+			walk(schema.Items.Schema)
+			for i := range schema.Items.Schemas {
+				walk(&schema.Items.Schemas[i])
+			}
+		}
+		if schema.AdditionalProperties != nil {
+			walk(schema.AdditionalProperties.Schema)
+		}
+		for _, list := range [][]spec.Schema{schema.AllOf, schema.AnyOf, schema.OneOf} {
+			for i := range list {
+				walk(&list[i])
+			}
+		}
+		walk(schema.Not)
+	}
+
+	for name, schema := range s.Definitions {
+		walk(&schema)
+		s.Definitions[name] = schema
+	}
+
+	walkOperation := func(op *spec.Operation) {
+		for i := range op.Parameters {
+			walk(op.Parameters[i].Schema)
+		}
+		if ws, ok := op.Extensions["x-websocket"].(webSocketMessages); ok {
+			walk(&ws.Receive)
+			walk(&ws.Send)
+			op.Extensions["x-websocket"] = ws
+		}
+		if op.Responses == nil {
+			return
+		}
+		if op.Responses.Default != nil {
+			walk(op.Responses.Default.Schema)
+		}
+		for _, res := range op.Responses.StatusCodeResponses {
+			walk(res.Schema)
+		}
+	}
+
+	for _, item := range s.Paths.Paths {
+		for _, op := range operations(item) {
+			walkOperation(op)
+		}
+	}
+	for _, item := range webhooks(s) {
+		for _, op := range operations(item) {
+			walkOperation(op)
+		}
+	}
+}
+
+// operations returns the operations of item.
+func operations(item spec.PathItem) []*spec.Operation {
+	var ops []*spec.Operation
+	for _, op := range []*spec.Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
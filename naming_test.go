@@ -0,0 +1,65 @@
+package pf
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+type Named struct {
+	Value int `json:"value"`
+}
+
+func (Named) SchemaName() string { return "Custom" }
+
+type Cookie struct {
+	Flavor string `json:"flavor"`
+}
+
+type Schedule struct {
+	Session http.Cookie `json:"session"`
+	Slot    Cookie      `json:"slot"`
+}
+
+func TestSchemaNames(t *testing.T) {
+	r := NewRouter()
+	Get(r, "/users", func(w ResponseWriter[Page[[]TestResponse]], r *Request[struct{}]) error {
+		return nil
+	})
+	Get(r, "/named", func(w ResponseWriter[Named], r *Request[struct{}]) error {
+		return nil
+	})
+	Get(r, "/schedule", func(w ResponseWriter[Schedule], r *Request[struct{}]) error {
+		return nil
+	})
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+	for _, name := range []string{"PageArrayTestResponse", "Custom", "http.Cookie", "pf.Cookie"} {
+		if _, ok := s.Definitions[name]; !ok {
+			t.Errorf("missing definition %q in %v", name, reflect.ValueOf(s.Definitions).MapKeys())
+		}
+	}
+	schema := s.Paths.Paths["/users"].Get.Responses.StatusCodeResponses[200].Schema
+	if ref := schema.Ref.String(); ref != "#/definitions/PageArrayTestResponse" {
+		t.Errorf("ref = %q", ref)
+	}
+	slot := s.Definitions["Schedule"].Properties["slot"]
+	if ref := slot.Ref.String(); ref != "#/definitions/pf.Cookie" {
+		t.Errorf("slot ref = %q", ref)
+	}
+
+	SetSchemaNamer(r, func(typ reflect.Type) string {
+		if typ == reflect.TypeFor[Schedule]() {
+			return "Custom"
+		}
+		return ""
+	})
+	if _, err := generateSpec(r, new(SwaggerInfo), nil); err == nil || !strings.Contains(err.Error(), `"Custom"`) {
+		t.Errorf("err = %v, want collision of Custom", err)
+	}
+}
@@ -0,0 +1,630 @@
+package pf
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"maps"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// AddOpenAPI generates the OpenAPI 3.1 spec from the handlers already created
+// in r and routes the Swagger spec page to endpoint. The spec is generated from
+// the same data as AddSwagger, with JSON Schema 2020-12 schemas under
+// components/schemas, and also documents what Swagger 2.0 can't: cookie
+// parameters, oneOf schemas (see RegisterOneOf) and webhooks (see Webhook).
+// The page is Swagger UI 5, as the UI of AddSwagger doesn't support OpenAPI
+// 3.1. Its assets aren't served by r: browsers load them from jsDelivr, or from
+// the location set with SetSwaggerUIAssets. Only call AddOpenAPI after routing
+// every handler you wish displayed on the page.
+func AddOpenAPI(r *Router, endpoint string, info *SwaggerInfo) error {
+	if info == nil {
+		info = new(SwaggerInfo)
+	}
+
+	r.info = info
+	doc, err := generateOpenAPI(r, info, nil)
+	if err != nil {
+		return err
+	}
+	slog.Info("swagger: generated OpenAPI 3.1 spec")
+
+	json, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	serveSpec(r, endpoint, "openapi.json", json, openAPIPage(r.swaggerUIAssets(), "openapi.json"))
+	return nil
+}
+
+// swaggerUIDist is the default location of the Swagger UI 5 distribution
+// loaded by the page of AddOpenAPI.
+const swaggerUIDist = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14"
+
+// SetSwaggerUIAssets makes the page of AddOpenAPI load swagger-ui.css and
+// swagger-ui-bundle.js from baseURL rather than jsDelivr, for networks without
+// access to it or to serve a vetted copy. baseURL is the location of a
+// swagger-ui-dist 5 distribution, such as "/assets/swagger-ui" routed to its
+// files with Handle. Call it before AddOpenAPI.
+func SetSwaggerUIAssets(r *Router, baseURL string) {
+	r.swaggerUI = strings.TrimSuffix(baseURL, "/")
+}
+
+// swaggerUIAssets returns the location of the Swagger UI distribution loaded
+// by the page of AddOpenAPI.
+func (r *Router) swaggerUIAssets() string {
+	if r.swaggerUI == "" {
+		return swaggerUIDist
+	}
+	return r.swaggerUI
+}
+
+var openAPIPageTemplate = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Swagger UI</title>
+  <link rel="stylesheet" href="{{.Dist}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.Dist}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: {{.URL}}, dom_id: "#swagger-ui", deepLinking: true});
+  </script>
+</body>
+</html>
+`))
+
+// openAPIPage returns the handler of the Swagger UI page loading its assets
+// from dist and displaying the spec at the relative URL name, served as
+// endpoint/index.html.
+func openAPIPage(dist, name string) http.HandlerFunc {
+	var page bytes.Buffer
+	err := openAPIPageTemplate.Execute(&page, map[string]string{"Dist": dist, "URL": "./" + name})
+	if err != nil {
+		panic(err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) != "index.html" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page.Bytes())
+	}
+}
+
+// jsonSchema is a JSON Schema 2020-12 document.
+type jsonSchema = map[string]any
+
+type openAPI struct {
+	OpenAPI           string                                  `json:"openapi"`
+	Info              *spec.Info                              `json:"info"`
+	JSONSchemaDialect string                                  `json:"jsonSchemaDialect"`
+	Tags              []spec.Tag                              `json:"tags,omitempty"`
+	Paths             map[string]map[string]*openAPIOperation `json:"paths"`
+	Webhooks          map[string]map[string]*openAPIOperation `json:"webhooks,omitempty"`
+	Components        openAPIComponents                       `json:"components"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]jsonSchema             `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type openAPIOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+
+	// Extensions are the x- members of the operation.
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON serializes op, flattening its extensions into the object.
+func (op *openAPIOperation) MarshalJSON() ([]byte, error) {
+	type operation openAPIOperation
+	if len(op.Extensions) == 0 {
+		return json.Marshal((*operation)(op))
+	}
+
+	members := make(map[string]any, len(op.Extensions))
+	for k, v := range op.Extensions {
+		members[k] = v
+	}
+
+	standard, err := json.Marshal((*operation)(op))
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(standard, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+type openAPIParameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+	Style       string     `json:"style,omitempty"`
+	Explode     *bool      `json:"explode,omitempty"`
+	Schema      jsonSchema `json:"schema,omitempty"`
+}
+
+type openAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema jsonSchema `json:"schema,omitempty"`
+}
+
+const problemMediaType = "application/problem+json"
+
+// generateOpenAPI generates the OpenAPI 3.1 spec of the handlers of r,
+// collecting generation warnings in warnings if not nil.
+func generateOpenAPI(r *Router, info *SwaggerInfo, warnings *specWarnings) (*openAPI, error) {
+	s, err := buildSpec(r, info, warnings)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := convertSpec(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, scheme := range collectSecuritySchemes(r) {
+		if doc.Components.SecuritySchemes == nil {
+			doc.Components.SecuritySchemes = make(map[string]*openAPISecurityScheme)
+		}
+		doc.Components.SecuritySchemes[name] = toOpenAPIScheme(scheme)
+	}
+
+	return doc, nil
+}
+
+// convertSpec converts a Swagger 2.0 spec generated by generateSpec to
+// OpenAPI 3.1.
+func convertSpec(s *spec.Swagger) (*openAPI, error) {
+	// Contact and license are optional, but must be valid when present
+	info := *s.Info
+	if info.Contact != nil && info.Contact.ContactInfoProps == (spec.ContactInfoProps{}) {
+		info.Contact = nil
+	}
+	if info.License != nil && info.License.Name == "" {
+		info.License = nil
+	}
+
+	doc := &openAPI{
+		OpenAPI:           "3.1.0",
+		Info:              &info,
+		Tags:              s.Tags,
+		JSONSchemaDialect: "https://spec.openapis.org/oas/3.1/dialect/base",
+		Paths:             make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: make(map[string]jsonSchema),
+		},
+	}
+
+	for name, schema := range s.Definitions {
+		converted, err := convertSchema(&schema)
+		if err != nil {
+			return nil, err
+		}
+		doc.Components.Schemas[name] = converted
+	}
+
+	if s.Paths != nil {
+		for path, item := range s.Paths.Paths {
+			methods, err := convertPathItem(item, s)
+			if err != nil {
+				return nil, err
+			}
+			doc.Paths[path] = methods
+		}
+	}
+
+	for name, item := range webhooks(s) {
+		methods, err := convertPathItem(item, s)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Webhooks == nil {
+			doc.Webhooks = make(map[string]map[string]*openAPIOperation)
+		}
+		doc.Webhooks[name] = methods
+	}
+
+	return doc, nil
+}
+
+// convertPathItem converts the operations of item by lowercase method.
+func convertPathItem(item spec.PathItem, s *spec.Swagger) (map[string]*openAPIOperation, error) {
+	ops := map[string]*spec.Operation{
+		"get":     item.Get,
+		"post":    item.Post,
+		"put":     item.Put,
+		"delete":  item.Delete,
+		"patch":   item.Patch,
+		"options": item.Options,
+		"head":    item.Head,
+	}
+
+	methods := make(map[string]*openAPIOperation)
+	for method, op := range ops {
+		if op == nil {
+			continue
+		}
+
+		converted, err := convertOperation(op, s)
+		if err != nil {
+			return nil, err
+		}
+		methods[method] = converted
+	}
+	return methods, nil
+}
+
+func convertOperation(op *spec.Operation, s *spec.Swagger) (*openAPIOperation, error) {
+	out := &openAPIOperation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Deprecated:  op.Deprecated,
+		Security:    op.Security,
+		Responses:   make(map[string]*openAPIResponse),
+		Extensions:  op.Extensions,
+	}
+
+	if ws, ok := op.Extensions["x-websocket"].(webSocketMessages); ok {
+		receive, err := convertSchema(&ws.Receive)
+		if err != nil {
+			return nil, err
+		}
+		send, err := convertSchema(&ws.Send)
+		if err != nil {
+			return nil, err
+		}
+		out.Extensions = maps.Clone(op.Extensions)
+		out.Extensions["x-websocket"] = map[string]jsonSchema{"receive": receive, "send": send}
+	}
+
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = s.Consumes
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+
+	var form jsonSchema
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "body":
+			schema, err := convertSchema(param.Schema)
+			if err != nil {
+				return nil, err
+			}
+			out.RequestBody = &openAPIRequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     mediaTypes(consumes, schema),
+			}
+
+		case "formData":
+			if form == nil {
+				form = jsonSchema{"type": "object", "properties": jsonSchema{}}
+			}
+			schema, err := paramSchema(&param)
+			if err != nil {
+				return nil, err
+			}
+			if param.Type == "file" {
+				schema = jsonSchema{"type": "string", "contentMediaType": "application/octet-stream"}
+			}
+			form["properties"].(jsonSchema)[param.Name] = schema
+			if param.Required {
+				required, _ := form["required"].([]string)
+				form["required"] = append(required, param.Name)
+			}
+
+		default:
+			converted, err := convertParameter(&param)
+			if err != nil {
+				return nil, err
+			}
+			out.Parameters = append(out.Parameters, converted)
+		}
+	}
+
+	// multipart/form-data bodies without declared fields
+	if out.RequestBody == nil && form == nil && contains(consumes, "multipart/form-data") && len(op.Consumes) > 0 {
+		form = jsonSchema{"type": "object"}
+	}
+	if form != nil {
+		out.RequestBody = &openAPIRequestBody{Content: mediaTypes(consumes, form)}
+	}
+
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = s.Produces
+	}
+
+	if op.Responses != nil {
+		if op.Responses.Default != nil {
+			res, err := convertResponse(op.Responses.Default, produces, true)
+			if err != nil {
+				return nil, err
+			}
+			out.Responses["default"] = res
+		}
+
+		for code, response := range op.Responses.StatusCodeResponses {
+			res, err := convertResponse(&response, produces, code >= 400)
+			if err != nil {
+				return nil, err
+			}
+			out.Responses[strconv.Itoa(code)] = res
+		}
+	}
+
+	return out, nil
+}
+
+// convertResponse converts a response. Problem Details are only documented
+// for error responses.
+func convertResponse(res *spec.Response, produces []string, isError bool) (*openAPIResponse, error) {
+	out := &openAPIResponse{Description: res.Description}
+	if res.Schema == nil {
+		return out, nil
+	}
+
+	schema, err := convertSchema(res.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []string
+	for _, mime := range produces {
+		if (mime == problemMediaType) == isError {
+			types = append(types, mime)
+		}
+	}
+	if len(types) == 0 {
+		types = produces
+	}
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+
+	out.Content = mediaTypes(types, schema)
+	return out, nil
+}
+
+func mediaTypes(types []string, schema jsonSchema) map[string]openAPIMediaType {
+	content := make(map[string]openAPIMediaType, len(types))
+	for _, mime := range types {
+		content[mime] = openAPIMediaType{Schema: schema}
+	}
+	return content
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaParamKeys are the members of a Swagger 2.0 parameter that belong to
+// its schema in OpenAPI 3.
+var schemaParamKeys = []string{
+	"type", "format", "items", "default", "maximum", "exclusiveMaximum",
+	"minimum", "exclusiveMinimum", "maxLength", "minLength", "pattern",
+	"maxItems", "minItems", "uniqueItems", "enum", "multipleOf",
+}
+
+func convertParameter(param *spec.Parameter) (openAPIParameter, error) {
+	out := openAPIParameter{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required || param.In == "path",
+	}
+
+	schema, err := paramSchema(param)
+	if err != nil {
+		return out, err
+	}
+	out.Schema = schema
+
+	explode := false
+	switch param.CollectionFormat {
+	case "multi":
+		explode = true
+		out.Style, out.Explode = "form", &explode
+	case "csv":
+		if param.In == "query" || param.In == "cookie" {
+			out.Style, out.Explode = "form", &explode
+		}
+	case "ssv":
+		out.Style = "spaceDelimited"
+	case "pipes":
+		out.Style = "pipeDelimited"
+	}
+
+	return out, nil
+}
+
+// paramSchema extracts the schema of a non-body parameter.
+func paramSchema(param *spec.Parameter) (jsonSchema, error) {
+	if param.Schema != nil {
+		return convertSchema(param.Schema)
+	}
+
+	raw, err := json.Marshal(param)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]any
+	err = json.Unmarshal(raw, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make(jsonSchema)
+	for _, key := range schemaParamKeys {
+		if v, ok := members[key]; ok {
+			schema[key] = v
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		delete(items, "collectionFormat")
+	}
+
+	return convertJSONSchema(schema), nil
+}
+
+// convertSchema converts a Swagger 2.0 schema to JSON Schema 2020-12.
+func convertSchema(s *spec.Schema) (jsonSchema, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema jsonSchema
+	err = json.Unmarshal(raw, &schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertJSONSchema(schema), nil
+}
+
+func convertJSONSchema(schema jsonSchema) jsonSchema {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema["$ref"] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+	}
+
+	if nullable, _ := schema["x-nullable"].(bool); nullable {
+		delete(schema, "x-nullable")
+		schema = nullableSchema(schema)
+	}
+
+	// Extension keys are lowercased by go-openapi
+	for ext, key := range map[string]string{"x-deprecated": "deprecated", "x-writeonly": "writeOnly"} {
+		if v, ok := schema[ext]; ok {
+			delete(schema, ext)
+			schema[key] = v
+		}
+	}
+
+	if example, ok := schema["example"]; ok {
+		delete(schema, "example")
+		schema["examples"] = []any{example}
+	}
+
+	if schema["type"] == "string" && schema["format"] == "byte" {
+		delete(schema, "format")
+		schema["contentEncoding"] = "base64"
+	}
+
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["contentMediaType"] = "application/octet-stream"
+	}
+
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		switch sub := schema[key].(type) {
+		case map[string]any:
+			schema[key] = convertJSONSchema(sub)
+		case []any:
+			// Tuple items
+			delete(schema, key)
+			schema["prefixItems"] = convertSchemas(sub)
+		}
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if sub, ok := schema[key].([]any); ok {
+			schema[key] = convertSchemas(sub)
+		}
+	}
+
+	for _, key := range []string{"properties", "patternProperties"} {
+		if props, ok := schema[key].(map[string]any); ok {
+			for name, prop := range props {
+				if prop, ok := prop.(map[string]any); ok {
+					props[name] = convertJSONSchema(prop)
+				}
+			}
+		}
+	}
+
+	return schema
+}
+
+func convertSchemas(schemas []any) []any {
+	for i, sub := range schemas {
+		if sub, ok := sub.(map[string]any); ok {
+			schemas[i] = convertJSONSchema(sub)
+		}
+	}
+	return schemas
+}
+
+// nullableSchema allows null in addition to the values matched by schema.
+func nullableSchema(schema jsonSchema) jsonSchema {
+	switch typ := schema["type"].(type) {
+	case string:
+		schema["type"] = []any{typ, "null"}
+		return schema
+	case []any:
+		schema["type"] = append(typ, "null")
+		return schema
+	}
+
+	if _, ok := schema["$ref"]; ok {
+		return jsonSchema{"anyOf": []any{schema, jsonSchema{"type": "null"}}}
+	}
+
+	// References wrapped to carry metadata
+	if allOf, ok := schema["allOf"].([]any); ok && len(allOf) == 1 {
+		delete(schema, "allOf")
+		schema["anyOf"] = append(allOf, jsonSchema{"type": "null"})
+		return schema
+	}
+
+	// Untyped schemas already match null
+	return schema
+}
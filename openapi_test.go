@@ -0,0 +1,186 @@
+package pf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	r := NewRouter()
+	UseProblemDetails(r)
+	Post(r, "/get", Ping, WithConsumes("application/json", "application/x-yaml"))
+	Get(r, "/items/{id}", func(w ResponseWriter[[]TestResponse], r *Request[BindRequest]) error {
+		return nil
+	})
+
+	doc, err := generateOpenAPI(r, &SwaggerInfo{Title: "PABLO"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		OpenAPI    string
+		Components struct{ Schemas map[string]jsonSchema }
+		Paths      map[string]map[string]struct {
+			Parameters  []openAPIParameter
+			RequestBody *openAPIRequestBody
+			Responses   map[string]openAPIResponse
+		}
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", out.OpenAPI)
+	}
+
+	post := out.Paths["/get"]["post"]
+	if post.RequestBody == nil || len(post.RequestBody.Content) != 2 {
+		t.Fatalf("request body = %+v", post.RequestBody)
+	}
+	if ref := post.RequestBody.Content["application/x-yaml"].Schema["$ref"]; ref != "#/components/schemas/TestRequest" {
+		t.Errorf("request body $ref = %v", ref)
+	}
+	if _, ok := post.Responses["default"].Content[problemMediaType]; !ok {
+		t.Errorf("default response = %+v", post.Responses["default"])
+	}
+	if _, ok := post.Responses["200"].Content[problemMediaType]; ok {
+		t.Errorf("200 response = %+v", post.Responses["200"])
+	}
+
+	b := out.Components.Schemas["TestRequest"]["properties"].(map[string]any)["B"].(map[string]any)
+	if _, ok := b["anyOf"]; !ok {
+		t.Errorf("nullable reference = %v", b)
+	}
+
+	get := out.Paths["/items/{id}"]["get"]
+	if len(get.Parameters) != 7 || get.Parameters[0].Schema["type"] != "integer" || !get.Parameters[0].Required {
+		t.Errorf("parameters = %+v", get.Parameters)
+	}
+	if tags := get.Parameters[2]; tags.Schema["type"] != "array" || tags.Explode == nil || !*tags.Explode {
+		t.Errorf("array parameter = %+v", tags)
+	}
+}
+
+type Payment interface{ payment() }
+
+type Card struct {
+	Number string `json:"number"`
+}
+
+type Transfer struct {
+	IBAN string `json:"iban"`
+}
+
+func (Card) payment()     {}
+func (Transfer) payment() {}
+
+type CheckoutRequest struct {
+	Session string `cookie:"session"`
+}
+
+type Receipt struct {
+	Payment Payment `json:"payment"`
+}
+
+type OrderShipped struct {
+	OrderID int `json:"orderId"`
+}
+
+func TestOpenAPIOnlyFeatures(t *testing.T) {
+	restoreSchemas(t)
+	RegisterOneOf(reflect.TypeFor[Payment](), reflect.TypeFor[Card](), reflect.TypeFor[Transfer]())
+
+	var session string
+	r := NewRouter()
+	Post(r, "/checkout", func(w ResponseWriter[Receipt], r *Request[CheckoutRequest]) error {
+		session = r.Body.Session
+		return w.OK(Receipt{Card{"4242"}})
+	})
+	Webhook[OrderShipped](r, "orderShipped", WithSummary("An order was shipped"))
+
+	req := httptest.NewRequest(http.MethodPost, "/checkout", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if session != "s3cr3t" {
+		t.Errorf("session = %q", session)
+	}
+
+	raw := must(json.Marshal(must(generateOpenAPI(r, new(SwaggerInfo), nil))))
+	var doc struct {
+		Paths      map[string]map[string]openAPIOperation
+		Webhooks   map[string]map[string]openAPIOperation
+		Components struct{ Schemas map[string]jsonSchema }
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	params := doc.Paths["/checkout"]["post"].Parameters
+	if len(params) != 1 || params[0].In != "cookie" || params[0].Name != "session" {
+		t.Errorf("parameters = %+v", params)
+	}
+	payment := doc.Components.Schemas["Receipt"]["properties"].(map[string]any)["payment"]
+	if got := must(json.Marshal(payment)); string(got) != `{"oneOf":[{"$ref":"#/components/schemas/Card"},{"$ref":"#/components/schemas/Transfer"}]}` {
+		t.Errorf("payment = %s", got)
+	}
+	hook := doc.Webhooks["orderShipped"]["post"]
+	if hook.Summary != "An order was shipped" || hook.RequestBody.Content["application/json"].Schema["$ref"] != "#/components/schemas/OrderShipped" {
+		t.Errorf("webhook = %+v", hook)
+	}
+
+	var warnings specWarnings
+	s := must(generateSpec(r, new(SwaggerInfo), &warnings))
+	if op := s.Paths.Paths["/checkout"].Post; len(op.Parameters) != 0 {
+		t.Errorf("swagger parameters = %+v", op.Parameters)
+	}
+	if p := s.Definitions["Receipt"].Properties["payment"]; p.OneOf != nil || len(p.Extensions["x-oneof"].([]spec.Schema)) != 2 {
+		t.Errorf("swagger payment = %+v", p)
+	}
+	if _, ok := s.Extensions[webhooksExtension]; ok || len(warnings) != 2 {
+		t.Errorf("swagger webhooks = %v, warnings = %q", s.Extensions, warnings)
+	}
+}
+
+func TestAddOpenAPI(t *testing.T) {
+	r := NewRouter()
+	Get(r, "/ping", Ping)
+	if err := AddOpenAPI(r, "/docs", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/index.html", nil))
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, swaggerUIDist+"/swagger-ui-bundle.js") || !strings.Contains(body, `openapi.json`) {
+		t.Errorf("page = %d %s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"openapi":"3.1.0"`) {
+		t.Errorf("spec = %d %s", rec.Code, rec.Body)
+	}
+
+	r = NewRouter()
+	SetSwaggerUIAssets(r, "/assets/swagger-ui/")
+	if err := AddOpenAPI(r, "/docs", nil); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/index.html", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `src="/assets/swagger-ui/swagger-ui-bundle.js"`) || strings.Contains(body, "jsdelivr") {
+		t.Errorf("page = %s", body)
+	}
+}
@@ -0,0 +1,100 @@
+package pf
+
+import (
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// pathParam is a URL parameter of a chi route pattern.
+type pathParam struct {
+	name string
+
+	// regex is the regular expression constraint of the parameter, if any.
+	regex string
+}
+
+// wildcardParam is the name of the parameter matching the trailing wildcard
+// of a pattern, as passed to chi.URLParam.
+const wildcardParam = "*"
+
+// parsePattern converts a chi route pattern to an OpenAPI path template and
+// returns its URL parameters. Regular expression constraints are dropped
+// from the template, so "/users/{id:[0-9]+}" becomes "/users/{id}", and a
+// trailing wildcard becomes the parameter "*". Repeated slashes resulting from
+// joining prefixes are collapsed.
+func parsePattern(pattern string) (string, []pathParam) {
+	var path strings.Builder
+	var params []pathParam
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '{':
+			// Find the matching brace, as regexes may contain braces
+			depth, end := 0, -1
+			for j := i; j < len(pattern) && end < 0; j++ {
+				switch pattern[j] {
+				case '{':
+					depth++
+				case '}':
+					if depth--; depth == 0 {
+						end = j
+					}
+				}
+			}
+			if end < 0 {
+				path.WriteString(pattern[i:])
+				i = len(pattern)
+				continue
+			}
+
+			name, regex, _ := strings.Cut(pattern[i+1:end], ":")
+			params = append(params, pathParam{name: name, regex: regex})
+			path.WriteString("{" + name + "}")
+			i = end
+
+		case c == '*' && i == len(pattern)-1:
+			params = append(params, pathParam{name: wildcardParam})
+			path.WriteString("{" + wildcardParam + "}")
+
+		case c == '/' && strings.HasSuffix(path.String(), "/"):
+
+		default:
+			path.WriteByte(c)
+		}
+	}
+
+	return path.String(), params
+}
+
+// addPathParams documents params in op, adding the constraints of parameters
+// already bound to request fields.
+func addPathParams(op *spec.Operation, params []pathParam) {
+	for _, param := range params {
+		var pattern string
+		if param.regex != "" {
+			pattern = "^" + param.regex + "$"
+		}
+
+		found := false
+		for i := range op.Parameters {
+			p := &op.Parameters[i]
+			if p.In == inPath && p.Name == param.name {
+				found = true
+				if p.Pattern == "" {
+					p.Pattern = pattern
+				}
+			}
+		}
+		if found {
+			continue
+		}
+
+		p := spec.PathParam(param.name).Typed("string", "")
+		p.Pattern = pattern
+		if param.name == wildcardParam {
+			p.Description = "The rest of the path"
+		}
+		op.Parameters = append(op.Parameters, *p)
+	}
+}
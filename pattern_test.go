@@ -0,0 +1,58 @@
+package pf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  []pathParam
+	}{
+		{"/ping", "/ping", nil},
+		{"/users/{id}", "/users/{id}", []pathParam{{"id", ""}}},
+		{"/users/{id:[0-9]+}/posts/{slug}", "/users/{id}/posts/{slug}", []pathParam{{"id", "[0-9]+"}, {"slug", ""}}},
+		{"/codes/{code:[a-z]{3}}", "/codes/{code}", []pathParam{{"code", "[a-z]{3}"}}},
+		{"/files/*", "/files/{*}", []pathParam{{"*", ""}}},
+		{"//wat//uploadbeer", "/wat/uploadbeer", nil},
+	}
+
+	for _, test := range tests {
+		path, params := parsePattern(test.pattern)
+		if path != test.path || !reflect.DeepEqual(params, test.params) {
+			t.Errorf("parsePattern(%q) = %q, %v; want %q, %v", test.pattern, path, params, test.path, test.params)
+		}
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	r := NewRouter()
+	Route(r, "/users/{user:[0-9]+}", func(r *Router) {
+		Get(r, "/items/{id}", func(w ResponseWriter[struct{}], r *Request[BindRequest]) error {
+			return nil
+		})
+		GetStd(r, "/files/*", nil, WithSummary("Files"))
+	})
+
+	paths := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths
+
+	op := paths["/users/{user}/items/{id}"].Get
+	if op == nil {
+		t.Fatalf("paths = %v", paths)
+	}
+	var user, id bool
+	for _, p := range op.Parameters {
+		user = user || p.In == "path" && p.Name == "user" && p.Required && p.Pattern == "^[0-9]+$"
+		id = id || p.In == "path" && p.Name == "id" && p.Type == "integer"
+	}
+	if !user || !id {
+		t.Errorf("parameters = %+v", op.Parameters)
+	}
+
+	op = paths["/users/{user}/files/{*}"].Get
+	if op == nil || len(op.Parameters) != 2 || op.Parameters[1].Name != "*" {
+		t.Errorf("wildcard operation = %+v", op)
+	}
+}
@@ -0,0 +1,176 @@
+package pf
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+)
+
+// Problem is an RFC 9457 Problem Details object. Handlers may return a
+// *Problem to respond with a custom detail and extension members; it is always
+// rendered as application/problem+json.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions are additional members serialized alongside the standard
+	// ones.
+	Extensions map[string]any `json:"-"`
+}
+
+// NewProblem returns a Problem with the specified status code, the standard
+// status text as its title and detail.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With returns a copy of p with the extension member key set to value.
+func (p *Problem) With(key string, value any) *Problem {
+	c := *p
+	c.Extensions = maps.Clone(p.Extensions)
+	if c.Extensions == nil {
+		c.Extensions = make(map[string]any)
+	}
+	c.Extensions[key] = value
+	return &c
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// Unwrap returns the error defined in the package for the status code of p,
+// so that errors.Is(p, ErrNotFound) holds for a 404 Problem.
+func (p *Problem) Unwrap() error {
+	return httpError(p.Status)
+}
+
+// MarshalJSON serializes p, flattening the extension members into the object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+
+	type problem Problem
+	standard, err := json.Marshal((*problem)(p))
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(standard, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON deserializes p, collecting the extension members into
+// Extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	type problem Problem
+	if err := json.Unmarshal(data, (*problem)(p)); err != nil {
+		return err
+	}
+
+	var members map[string]any
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for _, k := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(members, k)
+	}
+	if len(members) > 0 {
+		p.Extensions = members
+	}
+	return nil
+}
+
+// HandleProblem handles errors like HandleError, but responds with an
+// application/problem+json document instead of plain text. The Problem's
+// instance is set to the request path unless already present.
+func HandleProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	p := toProblem(err)
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+
+	writeProblem(w, p)
+}
+
+func toProblem(err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		p := *problem
+		if p.Status == 0 {
+			p.Status = http.StatusInternalServerError
+		}
+		return &p
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return NewProblem(http.StatusUnprocessableEntity, "Request validation failed").
+			With("errors", validationErr.Fields)
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		appErr.log()
+		p := NewProblem(appErr.status(), appErr.Message)
+		if appErr.Code != "" {
+			p.With("code", appErr.Code)
+		}
+		if appErr.Details != nil {
+			p.With("details", appErr.Details)
+		}
+		if appErr.Exposed && appErr.Err != nil {
+			p.With("cause", appErr.Err.Error())
+		}
+		return p
+	}
+
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		return NewProblem(http.StatusBadRequest, bindErr.Error())
+	}
+
+	var httpErr httpError
+	if errors.As(err, &httpErr) {
+		return NewProblem(int(httpErr), "")
+	}
+
+	slog.Error("Error in handler", "err", err.Error())
+	return NewProblem(http.StatusInternalServerError, "")
+}
+
+func writeProblem(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// UseProblemDetails sets the error handler of r to HandleProblem (see
+// SetErrorHandler). The generated spec then documents Problem as the default
+// response of every operation of r and its sub-routers.
+func UseProblemDetails(r *Router) {
+	SetErrorHandler(r, HandleProblem)
+	r.problems = true
+}
@@ -0,0 +1,74 @@
+package pf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProblemDetails(t *testing.T) {
+	r := NewRouter()
+	UseProblemDetails(r)
+	Route(r, "/orders", func(r *Router) {
+		Get(r, "/missing", func(w ResponseWriter[struct{}], r *Request[struct{}]) error {
+			return ErrNotFound
+		})
+		Post(r, "/conflict", func(w ResponseWriter[struct{}], r *Request[ValidatedItem]) error {
+			return NewProblem(http.StatusConflict, "order exists").With("id", 42)
+		})
+	})
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		members            map[string]any
+	}{
+		{http.MethodGet, "/orders/missing", "", 404, map[string]any{"title": "Not Found", "instance": "/orders/missing"}},
+		{http.MethodPost, "/orders/conflict", `{"name":"a"}`, 409, map[string]any{"detail": "order exists", "id": 42.0}},
+		{http.MethodPost, "/orders/conflict", `{`, 400, map[string]any{"status": 400.0}},
+		{http.MethodPost, "/orders/conflict", `{"name":"A"}`, 422, map[string]any{"title": "Unprocessable Entity"}},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+
+		if rec.Code != test.status {
+			t.Errorf("%s %s: status = %d, want %d", test.method, test.path, rec.Code, test.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("%s %s: content type = %q", test.method, test.path, ct)
+		}
+
+		var members map[string]any
+		if err := json.NewDecoder(rec.Body).Decode(&members); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range test.members {
+			if members[k] != v {
+				t.Errorf("%s %s: %s = %v, want %v", test.method, test.path, k, members[k], v)
+			}
+		}
+	}
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+	op := s.Paths.Paths["/orders/missing"].Get
+	if op.Responses.Default == nil || op.Responses.Default.Schema.Ref.String() != "#/definitions/Problem" {
+		t.Errorf("default response = %+v", op.Responses.Default)
+	}
+	if _, ok := s.Definitions["Problem"].Properties["-"]; ok {
+		t.Error("Problem definition contains ignored field")
+	}
+}
+
+func TestProblemWith(t *testing.T) {
+	base := NewProblem(http.StatusNotFound, "order not found").With("resource", "order")
+	first := base.With("id", 1)
+	second := base.With("id", 2)
+
+	if len(base.Extensions) != 1 || first.Extensions["id"] != 1 || second.Extensions["id"] != 2 || first.Extensions["resource"] != "order" {
+		t.Errorf("base = %v, first = %v, second = %v", base.Extensions, first.Extensions, second.Extensions)
+	}
+}
@@ -1,10 +1,11 @@
 package pf
 
 import (
-	"encoding/json"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"reflect"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,8 +14,19 @@ import (
 // Body is parsed depending on T:
 // If T is struct{}, then Body is equal to struct{}{};
 // If T is []byte, then the request body is read into Body;
-// If T is *multipart.Form, then the form data is fetched using ParseMultipartForm.
-// Otherwise, the response body is assumed to be JSON and deserialized into Body.
+// If T is *multipart.Form, then the form data is fetched using ParseMultipartForm;
+// If T is io.Reader or Stream, then the request body is handed over unbuffered.
+// Otherwise, the request body is decoded into Body by the decoder matching its
+// Content-Type: JSON (the default), form-urlencoded or one set with
+// SetDecoder. Other types are rejected with 415 Unsupported Media Type.
+//
+// Fields of a struct T tagged with `path:"name"`, `query:"name"` or
+// `header:"Name"` are populated from the URL parameters, the query string and
+// the headers respectively, converting the values to the field's type. Bound
+// fields may be strings, booleans, integers, floats, time.Duration, types
+// implementing encoding.TextUnmarshaler (such as time.Time), pointers to
+// those, or slices of those populated from repeated parameters. If T only
+// contains bound fields, the request body is not read.
 type Request[T any] struct {
 	*http.Request
 	Body T
@@ -25,27 +37,46 @@ func (r *Request[T]) URLParam(key string) string {
 	return chi.URLParam(r.Request, key)
 }
 
-func parseRequest[T any](r *http.Request) (*Request[T], error) {
+func parseRequest[T any](r *http.Request, decoders []mediaDecoder) (*Request[T], error) {
 	var body T
+	switch reflect.TypeFor[T]() {
+	case readerType:
+		return &Request[T]{r, any(r.Body).(T)}, nil
+	case streamType:
+		stream := Stream{Reader: r.Body, ContentType: r.Header.Get("Content-Type"), Length: r.ContentLength}
+		return &Request[T]{r, any(stream).(T)}, nil
+	}
+
+	b := getBinding(reflect.TypeFor[T]())
+
 	switch any(body).(type) {
 	case struct{}:
 	case []byte:
 		bytes, err := io.ReadAll(r.Body)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to read request body: %w", err)
 		}
 		body = any(bytes).(T)
 	case *multipart.Form:
 		err := r.ParseMultipartForm(32 << 20)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to parse multipart form: %w", err)
 		}
-		body = any(r.Form).(T)
+		body = any(r.MultipartForm).(T)
 	default:
-		err := json.NewDecoder(r.Body).Decode(&body)
+		if !b.body {
+			break
+		}
+		err := decodeBody(r.Body, r.Header.Get("Content-Type"), &body, decoders)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to parse request body: %w", err)
 		}
 	}
+
+	err := b.bind(r, reflect.ValueOf(&body).Elem())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Request[T]{r, body}, nil
 }
@@ -1,14 +1,31 @@
 package pf
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"reflect"
 )
 
 // ResponseWriter wraps an http.ResponseWriter instance, adding convenience
 // methods for marshaling the output.
 type ResponseWriter[T any] struct {
 	http.ResponseWriter
+
+	// responses are the response types declared with WithResponse.
+	responses map[int]reflect.Type
+
+	// accept is the Accept header of the request, and encoders returns the
+	// encoders of the router, for Respond.
+	accept   string
+	encoders func() []mediaEncoder
+
+	// ctx is the context of the request, ending streamed responses.
+	ctx context.Context
+
+	// req is the request, for serving files.
+	req *http.Request
 }
 
 // OK marshals response as JSON and sends an HTTP response with status code 200.
@@ -19,7 +36,70 @@ func (w *ResponseWriter[T]) OK(response T) error {
 // JSON marshals response as JSON and sends an HTTP response with the status
 // code specified by status.
 func (w *ResponseWriter[T]) JSON(status int, response T) error {
-	w.WriteHeader(status)
+	return w.writeJSON(status, response)
+}
+
+// Reply marshals response as JSON and sends an HTTP response with the status
+// code specified by status. The type of response must be the one declared for
+// status with WithResponse, or T if status has no declared response.
+func (w *ResponseWriter[T]) Reply(status int, response any) error {
+	if err := w.checkDeclared(status, response); err != nil {
+		return err
+	}
+	if isEmptyResponse(response) {
+		w.WriteHeader(status)
+		return nil
+	}
+	return w.writeJSON(status, response)
+}
+
+// Respond is like Reply, but encodes response in the media type best matching
+// the Accept header of the request among the encoders set with SetEncoder. It
+// returns ErrNotAcceptable if the client accepts none of them.
+func (w *ResponseWriter[T]) Respond(status int, response any) error {
+	if err := w.checkDeclared(status, response); err != nil {
+		return err
+	}
+	if isEmptyResponse(response) {
+		w.WriteHeader(status)
+		return nil
+	}
+
+	encoders := []mediaEncoder{{mediaType: "application/json", encode: EncodeJSON}}
+	if w.encoders != nil {
+		encoders = w.encoders()
+	}
+	e, ok := negotiate(w.accept, encoders)
+	if !ok {
+		return ErrNotAcceptable
+	}
+	return writeEncoded(w.ResponseWriter, status, e, response)
+}
+
+// checkDeclared checks that response has the type declared for status.
+func (w *ResponseWriter[T]) checkDeclared(status int, response any) error {
+	want, ok := w.responses[status]
+	if !ok {
+		want = reflect.TypeFor[T]()
+	}
+
+	typ := reflect.TypeOf(response)
+	if typ != nil && typ.Kind() == reflect.Pointer && want.Kind() != reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ != want && !(want == reflect.TypeFor[struct{}]() && response == nil) {
+		return fmt.Errorf("pf: response of type %v is not declared for status %d", typ, status)
+	}
+	return nil
+}
+
+func isEmptyResponse(response any) bool {
+	_, empty := response.(struct{})
+	return response == nil || empty
+}
+
+func (w *ResponseWriter[T]) writeJSON(status int, response any) error {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	return json.NewEncoder(w.ResponseWriter).Encode(response)
 }
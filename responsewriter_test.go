@@ -0,0 +1,70 @@
+package pf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+type ConflictBody struct {
+	Existing int `json:"existing"`
+}
+
+func TestDeclaredResponses(t *testing.T) {
+	r := NewRouter()
+	Put(r, "/orders/{id}", func(w ResponseWriter[TestResponse], r *Request[BindRequest]) error {
+		switch r.Body.ID {
+		case 1:
+			return w.Reply(http.StatusConflict, ConflictBody{Existing: 1})
+		case 2:
+			return w.Reply(http.StatusCreated, &TestResponse{})
+		case 3:
+			return w.Reply(http.StatusNoContent, nil)
+		default:
+			return w.Reply(http.StatusConflict, TestResponse{})
+		}
+	},
+		WithResponse[TestResponse](http.StatusCreated, "Order created"),
+		WithResponse[ConflictBody](http.StatusConflict, "Order already exists"),
+		WithResponse[struct{}](http.StatusNoContent, ""),
+		// User-defined properties modify the Swagger operation
+		func(op *spec.Operation) { op.ID = "putOrder" },
+	)
+
+	for id, want := range map[string]int{"1": 409, "2": 201, "3": 204, "4": 500} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/orders/"+id, nil))
+		if rec.Code != want {
+			t.Errorf("order %s: status = %d, want %d", id, rec.Code, want)
+		}
+		if want == 409 && !strings.Contains(rec.Body.String(), `"existing":1`) {
+			t.Errorf("order %s: body = %q", id, rec.Body.String())
+		}
+	}
+
+	op := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/orders/{id}"].Put
+	if op.ID != "putOrder" || len(op.Extensions) != 0 {
+		t.Errorf("operation = %+v", op)
+	}
+
+	// Properties applied outside pf leave the operation marshalable
+	var own spec.Operation
+	WithResponse[TestResponse](http.StatusCreated, "Order created")(&own)
+	if data, err := json.Marshal(own); err != nil || len(own.Extensions) != 0 {
+		t.Errorf("operation = %s, %v", data, err)
+	}
+	responses := op.Responses.StatusCodeResponses
+	if len(responses) != 4 {
+		t.Fatalf("responses = %v", responses)
+	}
+	if res := responses[409]; res.Description != "Order already exists" || res.Schema.Ref.String() != "#/definitions/ConflictBody" {
+		t.Errorf("409 = %+v", res)
+	}
+	if res := responses[204]; res.Description != "No Content" || res.Schema != nil {
+		t.Errorf("204 = %+v", res)
+	}
+}
@@ -4,6 +4,7 @@ package pf
 
 import (
 	"net/http"
+	"reflect"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -21,10 +22,34 @@ func (s signatures) add(path string, method string, signature *handlerSignature)
 // and response body signatures.
 type Router struct {
 	mux        chi.Router
+	parent     *Router
 	subrouters []*Router
 	prefix     string
 
 	signatures signatures
+
+	// webhooks are documented by Webhook.
+	webhooks signatures
+
+	errorHandler ErrorHandler
+	problems     bool
+
+	// props are applied to every handler of the router and its sub-routers.
+	props           []HandlerProperty
+	securitySchemes map[string]*SecurityScheme
+	schemaNamer     func(reflect.Type) string
+	encoders        []mediaEncoder
+	decoders        []mediaDecoder
+
+	webSocketOrigins []string
+
+	// swaggerUI is the location of the assets of the AddOpenAPI page, as set
+	// by SetSwaggerUIAssets.
+	swaggerUI string
+
+	// info documents the specs of the router, as set by AddSwagger,
+	// AddOpenAPI or SetSpecInfo.
+	info *SwaggerInfo
 }
 
 // NewRouter returns a newly initialized Router.
@@ -39,6 +64,50 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.mux.ServeHTTP(w, req)
 }
 
+// errorRouter returns the closest router installing an error handler
+// starting at r, or nil if there is none.
+func (r *Router) errorRouter() *Router {
+	for ; r != nil; r = r.parent {
+		if r.errorHandler != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// problemDetails reports whether the error handler of r is HandleProblem.
+func (r *Router) problemDetails() bool {
+	er := r.errorRouter()
+	return er != nil && er.problems
+}
+
+// handleError responds with err using the error handler of r.
+func (r *Router) handleError(w http.ResponseWriter, req *http.Request, err error) {
+	if er := r.errorRouter(); er != nil {
+		er.errorHandler(w, req, err)
+	} else {
+		HandleError(w, err)
+	}
+}
+
+// SetErrorHandler makes r respond to errors with h, which is used for request
+// parsing and validation failures, errors returned by handlers and panics.
+// Sub-routers inherit the error handler of their parent unless they set their
+// own. The default error handler is HandleError.
+func SetErrorHandler(r *Router, h ErrorHandler) {
+	r.errorHandler = h
+	r.problems = false
+}
+
+// groupProps returns the properties applied to every handler of r, starting
+// with those of its furthest parent.
+func (r *Router) groupProps() []HandlerProperty {
+	if r == nil {
+		return nil
+	}
+	return append(r.parent.groupProps(), r.props...)
+}
+
 func (r *Router) traverseSignatures() signatures {
 	out := make(signatures)
 	for k, v := range r.signatures {
@@ -64,12 +133,14 @@ func Use(r *Router, middlewares ...func(next http.Handler) http.Handler) {
 // Method also adds metadata, consisting of the request and response type,
 // as well as props for use by Swagger and the like.
 func Method[Req, Res any](r *Router, method string, path string, handler Handler[Req, Res], props ...HandlerProperty) {
-	h, signature := handler.wrap(props)
+	h, signature := handler.wrap(r, props)
 	r.mux.Method(method, path, h)
 	r.signatures.add(path, method, signature)
 }
 
-func Get[Res any](r *Router, path string, handler Handler[struct{}, Res], props ...HandlerProperty) {
+// Get adds a GET route for path. Res comes first, so that pf.Get[Res] still
+// instantiates it as before requests had parameters, inferring Req.
+func Get[Res, Req any](r *Router, path string, handler Handler[Req, Res], props ...HandlerProperty) {
 	Method(r, http.MethodGet, path, handler, props...)
 }
 
@@ -89,17 +160,23 @@ func Patch[Req, Res any](r *Router, path string, handler Handler[Req, Res], prop
 	Method(r, http.MethodPatch, path, handler, props...)
 }
 
+// Head adds a HEAD route for path. Use Method for HEAD handlers with request
+// parameters.
 func Head(r *Router, path string, handler Handler[struct{}, struct{}], props ...HandlerProperty) {
 	Method(r, http.MethodHead, path, handler, props...)
 }
 
-func Options[Res any](r *Router, path string, handler Handler[struct{}, Res], props ...HandlerProperty) {
+// Options adds an OPTIONS route for path. Like in Get, Res comes first.
+func Options[Res, Req any](r *Router, path string, handler Handler[Req, Res], props ...HandlerProperty) {
 	Method(r, http.MethodOptions, path, handler, props...)
 }
 
-// Route mounts a sub-router along path.
-func Route(r *Router, path string, fn func(r *Router)) {
+// Route mounts a sub-router along path. props are applied to every handler
+// of the sub-router before the handler's own props, so they may set tags,
+// security requirements, responses and the like for the whole group.
+func Route(r *Router, path string, fn func(r *Router), props ...HandlerProperty) {
 	subrouter := NewRouter()
+	subrouter.props = props
 	fn(subrouter)
 	Mount(r, path, subrouter)
 }
@@ -125,7 +202,8 @@ func MethodStd(r *Router, method string, path string, handler http.HandlerFunc,
 
 	if len(props) > 0 {
 		r.signatures.add(path, method, &handlerSignature{
-			props: props,
+			router: r,
+			props:  props,
 		})
 	}
 }
@@ -158,9 +236,12 @@ func OptionsStd(r *Router, path string, handler http.HandlerFunc, props ...Handl
 	MethodStd(r, http.MethodOptions, path, handler, props...)
 }
 
-// Mount mounts a sub-router along path.
-func Mount(r *Router, path string, subrouter *Router) {
+// Mount mounts a sub-router along path. Like in Route, props are applied to
+// every handler of the sub-router.
+func Mount(r *Router, path string, subrouter *Router, props ...HandlerProperty) {
+	subrouter.props = append(props[:len(props):len(props)], subrouter.props...)
 	r.mux.Mount(path, subrouter)
 	subrouter.prefix = path
+	subrouter.parent = r
 	r.subrouters = append(r.subrouters, subrouter)
 }
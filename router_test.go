@@ -0,0 +1,94 @@
+package pf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	var got []error
+	record := func(w http.ResponseWriter, r *http.Request, err error) {
+		got = append(got, err)
+		w.WriteHeader(http.StatusTeapot)
+	}
+
+	fail := func(w ResponseWriter[struct{}], r *Request[BindRequest]) error {
+		return ErrConflict
+	}
+	boom := func(w ResponseWriter[struct{}], r *Request[struct{}]) error {
+		panic("boom")
+	}
+
+	r := NewRouter()
+	SetErrorHandler(r, record)
+	Route(r, "/route", func(r *Router) {
+		Get(r, "/{id}", fail)
+		Get(r, "/panic/", boom)
+	})
+
+	mounted := NewRouter()
+	Get(mounted, "/{id}", fail)
+	Mount(r, "/mount", mounted)
+
+	own := NewRouter()
+	UseProblemDetails(own)
+	Get(own, "/{id}", fail)
+	Mount(r, "/own", own)
+
+	for _, path := range []string{"/route/1", "/route/x", "/route/panic/", "/mount/1", "/own/1"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		want := http.StatusTeapot
+		if strings.HasPrefix(path, "/own") {
+			want = http.StatusConflict
+		}
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("error handler called %d times, want 4", len(got))
+	}
+
+	var appErr *Error
+	if !errors.As(got[1], &appErr) || appErr.Status != http.StatusBadRequest || !errors.As(got[1], new(*BindError)) {
+		t.Errorf("parse error = %v", got[1])
+	}
+	if !strings.HasPrefix(got[2].Error(), "panic: boom") {
+		t.Errorf("panic error = %v", got[2])
+	}
+	if !errors.Is(got[3], ErrConflict) {
+		t.Errorf("mounted error = %v", got[3])
+	}
+}
+
+func TestMethodInstantiation(t *testing.T) {
+	r := NewRouter()
+
+	// The response type alone instantiates Get and Options, as before
+	// requests had parameters
+	Get[TestResponse](r, "/ping", func(w ResponseWriter[TestResponse], r *Request[struct{}]) error {
+		return w.OK(TestResponse{})
+	})
+	Get[TestResponse](r, "/items/{id}", func(w ResponseWriter[TestResponse], r *Request[BindRequest]) error {
+		return w.OK(TestResponse{})
+	})
+	Options[struct{}](r, "/ping", func(w ResponseWriter[struct{}], r *Request[struct{}]) error {
+		return nil
+	})
+	var head func(*Router, string, Handler[struct{}, struct{}], ...HandlerProperty) = Head
+	head(r, "/ping", func(w ResponseWriter[struct{}], r *Request[struct{}]) error { return nil })
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions, http.MethodHead} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, "/ping", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s /ping: status = %d", method, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package pf
+
+import (
+	"github.com/go-openapi/spec"
+)
+
+// SecurityScheme describes a way clients authenticate with the API. Use the
+// BearerAuth, BasicAuth, APIKeyAuth and OAuth2 constructors to create one.
+type SecurityScheme struct {
+	// Type is "http", "apiKey" or "oauth2".
+	Type        string
+	Description string
+
+	// Scheme is the HTTP authorization scheme of http schemes: "basic" or
+	// "bearer".
+	Scheme       string
+	BearerFormat string
+
+	// In is the location of the key of apiKey schemes: "header", "query" or
+	// "cookie". Name is the name of the header, query parameter or cookie.
+	In   string
+	Name string
+
+	// Flows are the flows supported by oauth2 schemes.
+	Flows OAuthFlows
+}
+
+// OAuthFlows are the OAuth2 flows supported by a SecurityScheme.
+type OAuthFlows struct {
+	Implicit          *OAuthFlow
+	Password          *OAuthFlow
+	ClientCredentials *OAuthFlow
+	AuthorizationCode *OAuthFlow
+}
+
+// OAuthFlow describes an OAuth2 flow. Scopes maps the scopes of the flow to
+// their descriptions.
+type OAuthFlow struct {
+	AuthorizationURL string
+	TokenURL         string
+	RefreshURL       string
+	Scopes           map[string]string
+}
+
+// BearerAuth returns an HTTP bearer token scheme. format is a hint of the
+// token format, such as "JWT", and may be empty.
+func BearerAuth(format string) *SecurityScheme {
+	return &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: format}
+}
+
+// BasicAuth returns an HTTP basic authentication scheme.
+func BasicAuth() *SecurityScheme {
+	return &SecurityScheme{Type: "http", Scheme: "basic"}
+}
+
+// APIKeyAuth returns an API key scheme with the key in the header, query
+// parameter or cookie (depending on in) called name.
+func APIKeyAuth(in, name string) *SecurityScheme {
+	return &SecurityScheme{Type: "apiKey", In: in, Name: name}
+}
+
+// OAuth2 returns an OAuth2 scheme supporting flows.
+func OAuth2(flows OAuthFlows) *SecurityScheme {
+	return &SecurityScheme{Type: "oauth2", Flows: flows}
+}
+
+// SecurityRequirement maps the names of security schemes that must all be
+// satisfied to the scopes required for each.
+type SecurityRequirement map[string][]string
+
+// Require returns a requirement of the security scheme called name with
+// scopes.
+func Require(name string, scopes ...string) SecurityRequirement {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return SecurityRequirement{name: scopes}
+}
+
+// AddSecurityScheme registers scheme under name for use in security
+// requirements of r's handlers. The schemes of every router are documented in
+// the spec.
+func AddSecurityScheme(r *Router, name string, scheme *SecurityScheme) {
+	if r.securitySchemes == nil {
+		r.securitySchemes = make(map[string]*SecurityScheme)
+	}
+	r.securitySchemes[name] = scheme
+}
+
+// WithSecurity sets the security requirements of the handler, overriding
+// those of its router. Any one of reqs must be satisfied; without reqs, the
+// handler requires no authentication.
+func WithSecurity(reqs ...SecurityRequirement) HandlerProperty {
+	return func(op *spec.Operation) {
+		op.Security = make([]map[string][]string, len(reqs))
+		for i, req := range reqs {
+			op.Security[i] = req
+		}
+	}
+}
+
+// UseSecurity sets the security requirements of every handler of r and its
+// sub-routers. Handlers and sub-routers may override them with WithSecurity
+// and UseSecurity respectively.
+func UseSecurity(r *Router, reqs ...SecurityRequirement) {
+	r.props = append(r.props, WithSecurity(reqs...))
+}
+
+// collectSecuritySchemes returns the security schemes of r, its parents and
+// its sub-routers.
+func collectSecuritySchemes(r *Router) map[string]*SecurityScheme {
+	schemes := make(map[string]*SecurityScheme)
+	for p := r.parent; p != nil; p = p.parent {
+		for name, scheme := range p.securitySchemes {
+			schemes[name] = scheme
+		}
+	}
+
+	var collect func(r *Router)
+	collect = func(r *Router) {
+		for name, scheme := range r.securitySchemes {
+			schemes[name] = scheme
+		}
+		for _, sub := range r.subrouters {
+			collect(sub)
+		}
+	}
+	collect(r)
+
+	return schemes
+}
+
+// toSwaggerScheme converts s to Swagger 2.0, or returns nil if Swagger 2.0
+// can't express it.
+func toSwaggerScheme(s *SecurityScheme) *spec.SecurityScheme {
+	var out *spec.SecurityScheme
+
+	switch {
+	case s.Type == "http" && s.Scheme == "basic":
+		out = spec.BasicAuth()
+	case s.Type == "http" && s.Scheme == "bearer":
+		out = spec.APIKeyAuth("Authorization", "header")
+		out.Description = "Bearer token, prefixed with \"Bearer \""
+	case s.Type == "apiKey" && (s.In == "header" || s.In == "query"):
+		out = spec.APIKeyAuth(s.Name, s.In)
+	case s.Type == "oauth2":
+		// Swagger 2.0 supports one flow per scheme
+		flows := s.Flows
+		switch {
+		case flows.AuthorizationCode != nil:
+			out = spec.OAuth2AccessToken(flows.AuthorizationCode.AuthorizationURL, flows.AuthorizationCode.TokenURL)
+			out.Scopes = flows.AuthorizationCode.Scopes
+		case flows.Implicit != nil:
+			out = spec.OAuth2Implicit(flows.Implicit.AuthorizationURL)
+			out.Scopes = flows.Implicit.Scopes
+		case flows.Password != nil:
+			out = spec.OAuth2Password(flows.Password.TokenURL)
+			out.Scopes = flows.Password.Scopes
+		case flows.ClientCredentials != nil:
+			out = spec.OAuth2Application(flows.ClientCredentials.TokenURL)
+			out.Scopes = flows.ClientCredentials.Scopes
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if s.Description != "" {
+		out.Description = s.Description
+	}
+	return out
+}
+
+// addSecurityDefinitions documents schemes in s, dropping requirements that
+// refer to schemes Swagger 2.0 can't express.
+func addSecurityDefinitions(s *spec.Swagger, schemes map[string]*SecurityScheme, warnings *specWarnings) {
+	if len(schemes) == 0 {
+		return
+	}
+
+	s.SecurityDefinitions = make(spec.SecurityDefinitions)
+	for name, scheme := range schemes {
+		if converted := toSwaggerScheme(scheme); converted != nil {
+			s.SecurityDefinitions[name] = converted
+		} else {
+			warnings.warn("swagger: security scheme not supported by Swagger 2.0", "name", name)
+		}
+	}
+
+	for _, item := range s.Paths.Paths {
+		for _, op := range operations(item) {
+			if op.Security == nil {
+				continue
+			}
+
+			security := op.Security[:0:0]
+			for _, req := range op.Security {
+				supported := true
+				for name := range req {
+					_, ok := s.SecurityDefinitions[name]
+					supported = supported && ok
+				}
+				if supported {
+					security = append(security, req)
+				}
+			}
+			op.Security = security
+		}
+	}
+}
+
+type openAPISecurityScheme struct {
+	Type         string             `json:"type"`
+	Description  string             `json:"description,omitempty"`
+	Scheme       string             `json:"scheme,omitempty"`
+	BearerFormat string             `json:"bearerFormat,omitempty"`
+	In           string             `json:"in,omitempty"`
+	Name         string             `json:"name,omitempty"`
+	Flows        *openAPIOAuthFlows `json:"flows,omitempty"`
+}
+
+type openAPIOAuthFlows struct {
+	Implicit          *openAPIOAuthFlow `json:"implicit,omitempty"`
+	Password          *openAPIOAuthFlow `json:"password,omitempty"`
+	ClientCredentials *openAPIOAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *openAPIOAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+type openAPIOAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// toOpenAPIScheme converts s to OpenAPI 3.
+func toOpenAPIScheme(s *SecurityScheme) *openAPISecurityScheme {
+	out := &openAPISecurityScheme{
+		Type:         s.Type,
+		Description:  s.Description,
+		Scheme:       s.Scheme,
+		BearerFormat: s.BearerFormat,
+		In:           s.In,
+		Name:         s.Name,
+	}
+
+	if s.Type == "oauth2" {
+		out.Flows = &openAPIOAuthFlows{
+			Implicit:          toOpenAPIFlow(s.Flows.Implicit),
+			Password:          toOpenAPIFlow(s.Flows.Password),
+			ClientCredentials: toOpenAPIFlow(s.Flows.ClientCredentials),
+			AuthorizationCode: toOpenAPIFlow(s.Flows.AuthorizationCode),
+		}
+	}
+
+	return out
+}
+
+func toOpenAPIFlow(f *OAuthFlow) *openAPIOAuthFlow {
+	if f == nil {
+		return nil
+	}
+
+	scopes := f.Scopes
+	if scopes == nil {
+		scopes = make(map[string]string)
+	}
+
+	return &openAPIOAuthFlow{
+		AuthorizationURL: f.AuthorizationURL,
+		TokenURL:         f.TokenURL,
+		RefreshURL:       f.RefreshURL,
+		Scopes:           scopes,
+	}
+}
@@ -0,0 +1,58 @@
+package pf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecurity(t *testing.T) {
+	r := NewRouter()
+	AddSecurityScheme(r, "bearer", BearerAuth("JWT"))
+	AddSecurityScheme(r, "session", APIKeyAuth("cookie", "session"))
+	AddSecurityScheme(r, "oauth", OAuth2(OAuthFlows{
+		AuthorizationCode: &OAuthFlow{
+			AuthorizationURL: "https://example.com/authorize",
+			TokenURL:         "https://example.com/token",
+			Scopes:           map[string]string{"orders:read": "Read orders"},
+		},
+	}))
+
+	Get(r, "/ping", Ping)
+	Route(r, "/orders", func(r *Router) {
+		UseSecurity(r, Require("bearer"), Require("session"))
+		Get(r, "/", Ping)
+		Get(r, "/public", Ping, WithSecurity())
+		Route(r, "/admin", func(r *Router) {
+			UseSecurity(r, Require("oauth", "orders:read"))
+			Get(r, "/", Ping)
+		})
+	})
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+	if len(s.SecurityDefinitions) != 2 || s.SecurityDefinitions["oauth"].Flow != "accessCode" {
+		t.Errorf("security definitions = %v", s.SecurityDefinitions)
+	}
+
+	tests := map[string][]map[string][]string{
+		"/ping":          nil,
+		"/orders/":       {{"bearer": {}}},
+		"/orders/public": {},
+		"/orders/admin/": {{"oauth": {"orders:read"}}},
+	}
+	for path, want := range tests {
+		if got := s.Paths.Paths[path].Get.Security; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: security = %v, want %v", path, got, want)
+		}
+	}
+
+	doc, err := generateOpenAPI(r, new(SwaggerInfo), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme := doc.Components.SecuritySchemes["bearer"]; scheme.Type != "http" || scheme.BearerFormat != "JWT" {
+		t.Errorf("bearer = %+v", scheme)
+	}
+	if got := doc.Paths["/orders/"]["get"].Security; len(got) != 2 {
+		t.Errorf("security = %v", got)
+	}
+}
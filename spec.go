@@ -0,0 +1,118 @@
+package pf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SpecFormat is a format of the spec written by Spec and WriteSpec.
+type SpecFormat string
+
+const (
+	SwaggerJSON SpecFormat = "swagger.json"
+	SwaggerYAML SpecFormat = "swagger.yaml"
+	OpenAPIJSON SpecFormat = "openapi.json"
+	OpenAPIYAML SpecFormat = "openapi.yaml"
+)
+
+// SetSpecInfo documents the specs of r with info. AddSwagger and AddOpenAPI
+// set the info too.
+func SetSpecInfo(r *Router, info *SwaggerInfo) {
+	r.info = info
+}
+
+// Spec generates the spec of the handlers of r in format, without serving
+// it, for export at build time. Warnings are problems making the spec
+// incomplete, such as unsupported types, which are also logged.
+func Spec(r *Router, format SpecFormat) (data []byte, warnings []string, err error) {
+	info := r.info
+	if info == nil {
+		info = new(SwaggerInfo)
+	}
+
+	var w specWarnings
+	var doc any
+	switch format {
+	case SwaggerJSON, SwaggerYAML:
+		doc, err = generateSpec(r, info, &w)
+	case OpenAPIJSON, OpenAPIYAML:
+		doc, err = generateOpenAPI(r, info, &w)
+	default:
+		return nil, nil, fmt.Errorf("pf: unknown spec format %q", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.HasSuffix(string(format), ".yaml") {
+		if data, err = jsonToYAML(data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return data, w, nil
+}
+
+// WriteSpec writes the spec of the handlers of r in format to w.
+func WriteSpec(r *Router, w io.Writer, format SpecFormat) error {
+	data, _, err := Spec(r, format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// jsonToYAML converts a JSON document to YAML, keeping the order of keys.
+func jsonToYAML(data []byte) ([]byte, error) {
+	// JSON is YAML written in flow style
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	var block func(n *yaml.Node)
+	block = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			block(child)
+		}
+	}
+	block(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// specWarnings collects the warnings of a spec generation.
+type specWarnings []string
+
+// warn logs a warning, recording it in w if not nil.
+func (w *specWarnings) warn(msg string, args ...any) {
+	slog.Warn(msg, args...)
+	if w == nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	*w = append(*w, b.String())
+}
@@ -0,0 +1,41 @@
+package pf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type MapResponse struct {
+	ByID map[int]string `json:"byId"`
+}
+
+func TestSpec(t *testing.T) {
+	r := NewRouter()
+	Post(r, "/get", Ping)
+	SetSpecInfo(r, &SwaggerInfo{Title: "PABLO", Version: "1.0"})
+
+	var buf bytes.Buffer
+	if err := WriteSpec(r, &buf, OpenAPIYAML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "openapi: 3.1.0\ninfo:\n  title: PABLO\n  version: \"1.0\"\n") {
+		t.Errorf("spec = %s", buf.String())
+	}
+
+	data, warnings, err := Spec(r, SwaggerJSON)
+	if err != nil || len(warnings) != 0 || !bytes.Contains(data, []byte(`"swagger": "2.0"`)) {
+		t.Errorf("spec = %s, warnings = %v, err = %v", data, warnings, err)
+	}
+
+	Get(r, "/map", func(w ResponseWriter[MapResponse], r *Request[struct{}]) error {
+		return nil
+	})
+	if _, warnings, _ := Spec(r, OpenAPIJSON); len(warnings) != 1 || !strings.Contains(warnings[0], "map[int]string") {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	if _, _, err := Spec(r, "openapi.toml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
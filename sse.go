@@ -0,0 +1,215 @@
+package pf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// SSEHandler streams events of type Event to the client with Server-Sent
+// Events. The stream ends when the handler returns. Errors returned before
+// the first event is sent are handled like those of Handler.
+type SSEHandler[Req, Event any] func(s *EventStream[Event], r *Request[Req]) error
+
+// ServerEvent is an event sent to the client of an event stream. Data is
+// encoded as JSON.
+type ServerEvent[T any] struct {
+	// ID is the id of the event, sent back by reconnecting clients in the
+	// Last-Event-ID header.
+	ID string
+
+	// Name is the type of the event, "message" if empty.
+	Name string
+
+	// Retry sets the time clients wait before reconnecting, if positive.
+	Retry time.Duration
+
+	Data T
+}
+
+// EventStream sends the events of a Server-Sent Events response. It is safe
+// for concurrent use.
+type EventStream[T any] struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	req *http.Request
+	ctx context.Context
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+}
+
+// SSE adds a GET route for path streaming the events sent by handler. The
+// operation is documented as producing text/event-stream with the schema of
+// Event.
+func SSE[Req, Event any](r *Router, path string, handler SSEHandler[Req, Event], props ...HandlerProperty) {
+	h, signature := handler.wrap(r, props)
+	r.mux.Method(http.MethodGet, path, h)
+	r.signatures.add(path, http.MethodGet, signature)
+}
+
+func (h SSEHandler[Req, Event]) wrap(router *Router, props []HandlerProperty) (http.HandlerFunc, *handlerSignature) {
+	parse := requestParser[Req](router)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		s := &EventStream[Event]{w: w, rc: http.NewResponseController(w), req: r, ctx: ctx}
+		defer func() {
+			cancel()
+			s.close()
+		}()
+
+		defer recoverPanic(func(err error) { s.fail(router, err) })
+
+		req, err := parse(r)
+		if err != nil {
+			router.handleError(w, r, err)
+			return
+		}
+
+		if err := h(s, req); err != nil {
+			s.fail(router, err)
+		}
+	}
+
+	return handler, &handlerSignature{
+		router:  router,
+		reqType: reflect.TypeFor[Req](),
+		resType: reflect.TypeFor[Event](),
+		events:  true,
+		props:   props,
+	}
+}
+
+// Context returns the context of the stream, done when the client
+// disconnects or the handler returns.
+func (s *EventStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+// LastEventID returns the id of the last event received by a reconnecting
+// client, for resuming the stream after it.
+func (s *EventStream[T]) LastEventID() string {
+	return s.req.Header.Get("Last-Event-ID")
+}
+
+// Send sends an unnamed event with data.
+func (s *EventStream[T]) Send(data T) error {
+	return s.SendEvent(ServerEvent[T]{Data: data})
+}
+
+// SendEvent sends e and flushes it to the client. It returns the error of the
+// context once the client disconnects.
+func (s *EventStream[T]) SendEvent(e ServerEvent[T]) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", sanitizeField(e.ID))
+	}
+	if e.Name != "" {
+		fmt.Fprintf(&b, "event: %s\n", sanitizeField(e.Name))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+	return s.write(b.String())
+}
+
+// Heartbeat sends a comment, keeping idle connections open through proxies.
+func (s *EventStream[T]) Heartbeat() error {
+	return s.write(":\n\n")
+}
+
+// KeepAlive sends a heartbeat every interval until the stream ends.
+func (s *EventStream[T]) KeepAlive(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if s.Heartbeat() != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (s *EventStream[T]) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.ctx.Err() != nil {
+		return context.Cause(s.ctx)
+	}
+
+	if !s.started {
+		h := s.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// close stops writes to the response once the handler returns.
+func (s *EventStream[T]) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+}
+
+// fail handles err with the error handler of router if no event was sent,
+// or logs it otherwise. The stream is closed first, so that heartbeats can't
+// start the response while the error is handled.
+func (s *EventStream[T]) fail(router *Router, err error) {
+	s.mu.Lock()
+	s.closed = true
+	started := s.started
+	s.mu.Unlock()
+
+	switch {
+	case !started:
+		router.handleError(s.w, s.req, err)
+	case !errors.Is(err, context.Canceled):
+		slog.Error("Error in event stream", "err", err.Error())
+	}
+}
+
+// sanitizeField strips line breaks, which would end a field of an event.
+func sanitizeField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// describeEvents documents the event stream of op with the schema of events.
+func describeEvents(op *spec.Operation, typ reflect.Type, structMap *structMap) {
+	schema := getType(typ, structMap)
+	op.Produces = []string{"text/event-stream"}
+	op.RespondsWith(http.StatusOK, spec.NewResponse().
+		WithDescription("Server-Sent Events stream; the data of each event is JSON").
+		WithSchema(&schema))
+}
@@ -0,0 +1,113 @@
+package pf
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type OrderStatus struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+func TestSSE(t *testing.T) {
+	r := NewRouter()
+	SSE(r, "/orders/{id}/events", func(s *EventStream[OrderStatus], r *Request[BindRequest]) error {
+		if r.Body.ID == 0 {
+			return ErrNotFound
+		}
+		if s.LastEventID() == "2" {
+			return s.Send(OrderStatus{ID: r.Body.ID, Status: "delivered"})
+		}
+		if err := s.SendEvent(ServerEvent[OrderStatus]{ID: "1", Name: "status", Retry: time.Second, Data: OrderStatus{ID: r.Body.ID, Status: "paid"}}); err != nil {
+			return err
+		}
+		return s.Heartbeat()
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/7/events", nil))
+	want := "id: 1\nevent: status\nretry: 1000\ndata: {\"id\":7,\"status\":\"paid\"}\n\n:\n\n"
+	if rec.Body.String() != want || rec.Header().Get("Content-Type") != "text/event-stream" || !rec.Flushed {
+		t.Errorf("stream = %q, headers = %v", rec.Body.String(), rec.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/7/events", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if want := "data: {\"id\":7,\"status\":\"delivered\"}\n\n"; rec.Body.String() != want {
+		t.Errorf("resumed stream = %q, want %q", rec.Body.String(), want)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/0/events", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+
+	op := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/orders/{id}/events"].Get
+	if op.Produces[0] != "text/event-stream" || op.Responses.StatusCodeResponses[200].Schema.Ref.String() != "#/definitions/OrderStatus" {
+		t.Errorf("operation = %+v", op)
+	}
+}
+
+func TestSSEDisconnect(t *testing.T) {
+	done := make(chan error, 1)
+	r := NewRouter()
+	SSE(r, "/ticks", func(s *EventStream[int], r *Request[struct{}]) error {
+		s.KeepAlive(time.Millisecond)
+		for i := 0; ; i++ {
+			if err := s.Send(i); err != nil {
+				done <- err
+				return err
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/ticks", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, _ := bufio.NewReader(res.Body).ReadString('\n')
+	if !strings.HasPrefix(line, "data: ") {
+		t.Errorf("first line = %q", line)
+	}
+	cancel()
+	res.Body.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not stop after the client disconnected")
+	}
+}
+
+func TestSSEFailWithKeepAlive(t *testing.T) {
+	r := NewRouter()
+	SetErrorHandler(r, func(w http.ResponseWriter, r *http.Request, err error) {
+		// Leave heartbeats time to start the stream
+		time.Sleep(10 * time.Millisecond)
+		HandleError(w, err)
+	})
+	SSE(r, "/events", func(s *EventStream[OrderStatus], r *Request[struct{}]) error {
+		s.KeepAlive(time.Millisecond)
+		return ErrConflict
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if rec.Code != http.StatusConflict || strings.Contains(rec.Body.String(), ":\n\n") {
+		t.Errorf("response = %d %q", rec.Code, rec.Body.String())
+	}
+}
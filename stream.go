@@ -0,0 +1,136 @@
+package pf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/go-openapi/spec"
+)
+
+// Stream is a request body type handing the body to the handler unbuffered,
+// like io.Reader, along with its metadata. The body is not validated.
+type Stream struct {
+	io.Reader
+
+	// ContentType is the Content-Type header of the request.
+	ContentType string
+
+	// Length is the length of the body, or -1 if unknown.
+	Length int64
+}
+
+var (
+	readerType = reflect.TypeFor[io.Reader]()
+	streamType = reflect.TypeFor[Stream]()
+)
+
+// isStreamBody reports whether typ is a request body type read by handlers
+// as a stream.
+func isStreamBody(typ reflect.Type) bool {
+	return typ == readerType || typ == streamType
+}
+
+// seqItem returns the item type of typ if it is a function type ranged over
+// like iter.Seq, which handlers streaming JSON lines declare as their response
+// type.
+func seqItem(typ reflect.Type) (reflect.Type, bool) {
+	if typ == nil || typ.Kind() != reflect.Func || !typ.CanSeq() {
+		return nil, false
+	}
+	return typ.In(0).In(0), true
+}
+
+// describeStreamBody documents the binary request body of op.
+func describeStreamBody(op *spec.Operation) {
+	op.Consumes = []string{"application/octet-stream"}
+	body := spec.StrFmtProperty("binary")
+	op.Parameters = append(op.Parameters, *spec.BodyParam("body", body).AsRequired())
+}
+
+// Flush sends the data written so far to the client.
+func (w *ResponseWriter[T]) Flush() error {
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// describeStreamResponse documents the JSON lines of items sent by Stream.
+func describeStreamResponse(op *spec.Operation, item reflect.Type, structMap *structMap) {
+	schema := getType(item, structMap)
+	op.Produces = []string{"application/x-ndjson"}
+	op.RespondsWith(http.StatusOK, spec.NewResponse().
+		WithDescription("Stream of JSON lines (NDJSON), one per item").
+		WithSchema(&schema))
+}
+
+// Stream sends the items of seq as JSON lines (NDJSON), flushing each of
+// them to the client as soon as it is encoded. The response type of the
+// handler must be iter.Seq[Item], documented as application/x-ndjson with the
+// schema of Item. Iteration stops when the client disconnects, and Stream
+// returns the error of the request context.
+func (w *ResponseWriter[T]) Stream(seq T) error {
+	v := reflect.ValueOf(seq)
+	if _, ok := seqItem(reflect.TypeFor[T]()); !ok || v.IsNil() {
+		return fmt.Errorf("pf: Stream called with %T, not an iter.Seq", seq)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w.ResponseWriter)
+	for item := range v.Seq() {
+		if err := w.context().Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(item.Interface()); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+	return w.context().Err()
+}
+
+// Copy sends the data read from r as contentType in chunks, flushing each
+// of them to the client. Handlers copying data declare io.Reader as their
+// response type, documented as a binary response; WithProduces documents
+// content types other than application/octet-stream. Copying stops when the
+// client disconnects, and Copy returns the error of the request context.
+func (w *ResponseWriter[T]) Copy(contentType string, r io.Reader) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+
+	buf := make([]byte, 32<<10)
+	for {
+		if err := w.context().Err(); err != nil {
+			return err
+		}
+
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+			if err := w.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (w *ResponseWriter[T]) context() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
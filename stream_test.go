@@ -0,0 +1,141 @@
+package pf
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamRequest(t *testing.T) {
+	r := NewRouter()
+	Post(r, "/raw", func(w ResponseWriter[int64], r *Request[io.Reader]) error {
+		n, err := io.Copy(io.Discard, r.Body)
+		if err != nil {
+			return err
+		}
+		return w.OK(n)
+	})
+	Post(r, "/upload", func(w ResponseWriter[string], r *Request[Stream]) error {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		return w.OK(r.Body.ContentType + " " + string(data))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/raw", strings.NewReader(strings.Repeat("x", 1<<16))))
+	if rec.Body.String() != "65536\n" {
+		t.Errorf("raw = %q", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("beer"))
+	req.Header.Set("Content-Type", "text/csv")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Body.String() != "\"text/csv beer\"\n" {
+		t.Errorf("upload = %q", rec.Body.String())
+	}
+
+	op := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/upload"].Post
+	if op.Consumes[0] != "application/octet-stream" || op.Parameters[0].Schema.Format != "binary" {
+		t.Errorf("operation = %+v", op)
+	}
+}
+
+func TestStreamResponse(t *testing.T) {
+	r := NewRouter()
+	Get(r, "/beers", func(w ResponseWriter[iter.Seq[Beer]], r *Request[struct{}]) error {
+		return w.Stream(func(yield func(Beer) bool) {
+			yield(Beer{Name: "Stout", ABV: 4.2})
+			yield(Beer{Name: "Lager", ABV: 5})
+		})
+	})
+	Get(r, "/export", func(w ResponseWriter[io.Reader], r *Request[struct{}]) error {
+		return w.Copy("text/csv", strings.NewReader("name\nStout\n"))
+	}, WithProduces("text/csv"))
+	Get(r, "/broken", func(w ResponseWriter[iter.Seq[Beer]], r *Request[struct{}]) error {
+		err := w.Stream(func(yield func(Beer) bool) {
+			yield(Beer{Name: "Stout"})
+		})
+		if err != nil {
+			return err
+		}
+		return errors.New("database gone")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/beers", nil))
+	want := "{\"name\":\"Stout\",\"abv\":4.2}\n{\"name\":\"Lager\",\"abv\":5}\n"
+	if rec.Body.String() != want || rec.Header().Get("Content-Type") != "application/x-ndjson" || !rec.Flushed {
+		t.Errorf("stream = %q, headers = %v", rec.Body.String(), rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if rec.Body.String() != "name\nStout\n" || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("export = %q, headers = %v", rec.Body.String(), rec.Header())
+	}
+
+	// Errors after the response started are not responded to
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"name\":\"Stout\",\"abv\":0}\n" {
+		t.Errorf("broken = %d %q", rec.Code, rec.Body.String())
+	}
+
+	paths := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths
+	beers := paths["/beers"].Get
+	if beers.Produces[0] != "application/x-ndjson" || beers.Responses.StatusCodeResponses[200].Schema.Ref.String() != "#/definitions/Beer" {
+		t.Errorf("beers = %+v", beers)
+	}
+	export := paths["/export"].Get
+	if export.Produces[0] != "text/csv" || !export.Responses.StatusCodeResponses[200].Schema.Type.Contains("file") {
+		t.Errorf("export = %+v", export)
+	}
+}
+
+func TestStreamDisconnect(t *testing.T) {
+	done := make(chan error, 1)
+	r := NewRouter()
+	Get(r, "/ticks", func(w ResponseWriter[iter.Seq[int]], r *Request[struct{}]) error {
+		var ticks iter.Seq[int] = func(yield func(int) bool) {
+			for i := 0; yield(i); i++ {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		err := w.Stream(ticks)
+		done <- err
+		return err
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/ticks", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := bufio.NewReader(res.Body).ReadString('\n'); line != "0\n" {
+		t.Errorf("first line = %q", line)
+	}
+	cancel()
+	res.Body.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Stream returned nil after the client disconnected")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop after the client disconnected")
+	}
+}
@@ -7,7 +7,8 @@ import (
 	"net/http"
 	"path"
 	"reflect"
-	"strings"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/go-openapi/spec"
@@ -22,7 +23,11 @@ func AddSwagger(r *Router, endpoint string, info *SwaggerInfo) error {
 		info = new(SwaggerInfo)
 	}
 
-	s := generateSpec(r.traverseSignatures(), info)
+	r.info = info
+	s, err := generateSpec(r, info, nil)
+	if err != nil {
+		return err
+	}
 	slog.Info("swagger: generated spec")
 
 	json, err := s.MarshalJSON()
@@ -30,19 +35,24 @@ func AddSwagger(r *Router, endpoint string, info *SwaggerInfo) error {
 		return err
 	}
 
+	serveSpec(r, endpoint, "swagger.json", json, httpSwagger.Handler(httpSwagger.URL("./swagger.json")))
+	return nil
+}
+
+// serveSpec routes the spec to endpoint/name and the Swagger UI displaying it
+// to endpoint.
+func serveSpec(r *Router, endpoint string, name string, json []byte, ui http.Handler) {
 	buffer := bytes.NewReader(json)
 
 	r.mux.Get(
-		path.Join(endpoint, "swagger.json"),
+		path.Join(endpoint, name),
 		func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
-			http.ServeContent(w, r, "swagger.json", time.Time{}, buffer)
+			http.ServeContent(w, r, name, time.Time{}, buffer)
 		},
 	)
 
-	handler := httpSwagger.Handler(httpSwagger.URL("./swagger.json"))
-
-	r.mux.Get(path.Join(endpoint, "*"), handler)
+	r.mux.Get(path.Join(endpoint, "*"), ui.ServeHTTP)
 
 	redirect := func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, path.Join(endpoint, "index.html"), http.StatusFound)
@@ -50,14 +60,62 @@ func AddSwagger(r *Router, endpoint string, info *SwaggerInfo) error {
 	r.mux.Get(endpoint, redirect)
 
 	slog.Info("swagger: added handler", "endpoint", endpoint)
+}
 
-	return nil
+type declaredResponse struct {
+	typ         reflect.Type
+	description string
 }
 
 // HandlerProperty represents a modification to the handler's metadata
 // (summary, description etc.) for Swagger.
 type HandlerProperty func(op *spec.Operation)
 
+// responseCollectors holds the responses declared with WithResponse for the
+// operations applyProps is applying props to.
+var responseCollectors sync.Map // map[*spec.Operation]map[int]declaredResponse
+
+// applyProps applies props to op, returning the responses declared with
+// WithResponse.
+func applyProps(op *spec.Operation, props []HandlerProperty) map[int]declaredResponse {
+	responses := make(map[int]declaredResponse)
+	responseCollectors.Store(op, responses)
+	defer responseCollectors.Delete(op)
+
+	for _, prop := range props {
+		prop(op)
+	}
+	return responses
+}
+
+// declaredResponses returns the types of the responses declared by props.
+func declaredResponses(props []HandlerProperty) map[int]reflect.Type {
+	responses := applyProps(new(spec.Operation), props)
+
+	types := make(map[int]reflect.Type, len(responses))
+	for status, res := range responses {
+		types[status] = res.typ
+	}
+	return types
+}
+
+// WithResponse declares an additional response of the handler with the status
+// code status and a body of type T. Use struct{} for responses without a body.
+// Handlers send declared responses with ResponseWriter.Reply.
+func WithResponse[T any](status int, description string) HandlerProperty {
+	return func(op *spec.Operation) {
+		// Operations props aren't applied to by pf are left unchanged
+		responses, ok := responseCollectors.Load(op)
+		if !ok {
+			return
+		}
+		responses.(map[int]declaredResponse)[status] = declaredResponse{
+			typ:         reflect.TypeFor[T](),
+			description: description,
+		}
+	}
+}
+
 // WithSummary sets the handler's summary.
 func WithSummary(summary string) HandlerProperty {
 	return func(op *spec.Operation) {
@@ -100,6 +158,25 @@ func WithProduces(mime ...string) HandlerProperty {
 	}
 }
 
+// WithTags adds tags to the handler, grouping it with other handlers sharing
+// them in Swagger UI.
+func WithTags(tags ...string) HandlerProperty {
+	return func(op *spec.Operation) {
+		for _, tag := range tags {
+			if !contains(op.Tags, tag) {
+				op.Tags = append(op.Tags, tag)
+			}
+		}
+	}
+}
+
+// WithDeprecated marks the handler as deprecated.
+func WithDeprecated() HandlerProperty {
+	return func(op *spec.Operation) {
+		op.Deprecated = true
+	}
+}
+
 // SwaggerInfo represents metadata about the API.
 type SwaggerInfo struct {
 	Title          string
@@ -111,6 +188,36 @@ type SwaggerInfo struct {
 	License        string
 	LicenseURL     string
 	Version        string
+
+	// Tags describe the tags used by handlers. Tags are listed in Swagger UI
+	// in this order.
+	Tags []Tag
+}
+
+// Tag describes a tag added to handlers with WithTags.
+type Tag struct {
+	Name        string
+	Description string
+
+	// ExternalDocsURL links to further documentation of the tag, described
+	// by ExternalDocsDescription.
+	ExternalDocsURL         string
+	ExternalDocsDescription string
+}
+
+func toSpecTags(tags []Tag) []spec.Tag {
+	var out []spec.Tag
+	for _, tag := range tags {
+		var docs *spec.ExternalDocumentation
+		if tag.ExternalDocsURL != "" {
+			docs = &spec.ExternalDocumentation{
+				Description: tag.ExternalDocsDescription,
+				URL:         tag.ExternalDocsURL,
+			}
+		}
+		out = append(out, spec.NewTag(tag.Name, tag.Description, docs))
+	}
+	return out
 }
 
 func toSpecInfo(i *SwaggerInfo) *spec.Info {
@@ -137,34 +244,88 @@ func toSpecInfo(i *SwaggerInfo) *spec.Info {
 	}
 }
 
-type structMap map[reflect.Type]spec.Schema
+// generateSpec generates the Swagger 2.0 spec of the handlers of r,
+// collecting generation warnings in warnings if not nil.
+func generateSpec(r *Router, info *SwaggerInfo, warnings *specWarnings) (*spec.Swagger, error) {
+	s, err := buildSpec(r, info, warnings)
+	if err != nil {
+		return nil, err
+	}
+	addSecurityDefinitions(s, collectSecuritySchemes(r), warnings)
+	removeOpenAPIFeatures(s, warnings)
+	return s, nil
+}
+
+// removeOpenAPIFeatures removes the parts of s that only OpenAPI 3.1 can
+// document: cookie parameters and webhooks are left out, and oneOf schemas
+// become free-form, listing their variants in x-oneof.
+func removeOpenAPIFeatures(s *spec.Swagger, warnings *specWarnings) {
+	for path, item := range s.Paths.Paths {
+		for _, op := range operations(item) {
+			op.Parameters = slices.DeleteFunc(op.Parameters, func(p spec.Parameter) bool {
+				if p.In != inCookie {
+					return false
+				}
+				warnings.warn("swagger: cookie parameter not supported by Swagger 2.0", "path", path, "name", p.Name)
+				return true
+			})
+
+			// Body parameters can't be sent as forms in Swagger 2.0
+			if slices.ContainsFunc(op.Parameters, func(p spec.Parameter) bool { return p.In == "body" }) {
+				op.Consumes = slices.DeleteFunc(op.Consumes, isFormType)
+			}
+		}
+	}
+
+	if len(webhooks(s)) > 0 {
+		warnings.warn("swagger: webhooks not supported by Swagger 2.0")
+		delete(s.Extensions, webhooksExtension)
+	}
+
+	walkSchemas(s, func(schema *spec.Schema) {
+		if len(schema.OneOf) > 0 {
+			schema.AddExtension("x-oneof", schema.OneOf)
+			schema.OneOf = nil
+		}
+	})
+}
+
+// buildSpec builds the spec of the handlers of r, which is converted to
+// Swagger 2.0 or OpenAPI 3.1.
+func buildSpec(r *Router, info *SwaggerInfo, warnings *specWarnings) (*spec.Swagger, error) {
+	signatures := r.traverseSignatures()
 
-func generateSpec(signatures signatures, info *SwaggerInfo) *spec.Swagger {
 	var s spec.Swagger
 	s.Swagger = "2.0"
 	s.Info = toSpecInfo(info)
+	s.Tags = toSpecTags(info.Tags)
 	s.Paths = &spec.Paths{Paths: make(map[string]spec.PathItem)}
 	s.Definitions = make(spec.Definitions)
 
 	// Store definitions to use later
-	structMap := make(structMap)
+	structMap := newStructMap(warnings)
 
-	for path, methods := range signatures {
-		s.Paths.Paths[path] = createPathItem(methods, structMap)
+	for pattern, methods := range signatures {
+		path, params := parsePattern(pattern)
+		s.Paths.Paths[path] = createPathItem(s.Paths.Paths[path], methods, params, structMap)
 	}
+	createWebhooks(&s, r, structMap)
 
-	for typ, schema := range structMap {
-		s.Definitions[typ.Name()] = schema
+	names, err := structMap.name(r.namer())
+	if err != nil {
+		return nil, err
+	}
+	for typ, schema := range structMap.schemas {
+		s.Definitions[names[typ]] = schema
 	}
+	structMap.resolveRefs(&s, names)
 
-	return &s
+	return &s, nil
 }
 
-func createPathItem(methods map[string]*handlerSignature, structMap structMap) spec.PathItem {
-	var item spec.PathItem
-
+func createPathItem(item spec.PathItem, methods map[string]*handlerSignature, params []pathParam, structMap *structMap) spec.PathItem {
 	for method, sig := range methods {
-		op := createOperation(sig, structMap)
+		op := createOperation(sig, params, structMap)
 
 		switch method {
 		case http.MethodGet:
@@ -182,63 +343,215 @@ func createPathItem(methods map[string]*handlerSignature, structMap structMap) s
 		case http.MethodHead:
 			item.Head = op
 		default:
-			slog.Warn("swagger: unsupported method", "method", method)
+			structMap.warnings.warn("swagger: unsupported method", "method", method)
 		}
 	}
 
 	return item
 }
 
-func createOperation(sig *handlerSignature, structMap structMap) *spec.Operation {
+func createOperation(sig *handlerSignature, params []pathParam, structMap *structMap) *spec.Operation {
 	var op spec.Operation
 
-	switch sig.reqType {
-	case nil, reflect.TypeFor[struct{}]():
-	case reflect.TypeFor[*multipart.Form]():
+	switch {
+	case sig.webSocket:
+		describeWebSocket(&op, sig, structMap)
+	case sig.reqType == nil, sig.reqType == reflect.TypeFor[struct{}]():
+	case isStreamBody(sig.reqType):
+		describeStreamBody(&op)
+	case sig.reqType == reflect.TypeFor[*multipart.Form]():
 		op.Consumes = []string{"multipart/form-data"}
 	default:
-		op.Consumes = []string{"application/json"}
-		req := getType(sig.reqType, structMap)
-		op.Parameters = append(op.Parameters, *spec.BodyParam("body", &req))
+		op.Parameters = append(op.Parameters, createParameters(sig.reqType)...)
+		if getBinding(sig.reqType).body {
+			op.Consumes = []string{"application/json"}
+			if sig.router != nil {
+				describeDecoders(&op, sig.router, sig.reqType)
+			}
+			req := getType(sig.reqType, structMap)
+			op.Parameters = append(op.Parameters, *spec.BodyParam("body", &req).AsRequired())
+		}
 	}
 
-	switch sig.resType {
-	case nil, reflect.TypeFor[struct{}]():
+	addPathParams(&op, params)
+
+	switch {
+	case sig.webSocket:
+	case sig.events:
+		describeEvents(&op, sig.resType, structMap)
 	default:
 		describeResponse(&op, sig.resType, structMap)
 	}
-
-	for _, prop := range sig.props {
-		prop(&op)
+	if sig.router != nil && sig.router.problemDetails() {
+		describeProblem(&op, structMap)
 	}
 
+	describeEncoders(&op, sig.router, sig.resType)
+
+	responses := applyProps(&op, slices.Concat(sig.router.groupProps(), sig.props))
+	describeDeclaredResponses(&op, responses, structMap)
+
 	return &op
 }
 
-func describeResponse(op *spec.Operation, typ reflect.Type, structMap structMap) {
+// createParameters describes the path, query, header and cookie parameters
+// bound to the fields of typ.
+func createParameters(typ reflect.Type) []spec.Parameter {
+	var params []spec.Parameter
+
+	for _, field := range getBinding(typ).params {
+		param := spec.Parameter{
+			ParamProps: spec.ParamProps{
+				In:       field.in,
+				Name:     field.name,
+				Required: field.in == inPath,
+			},
+		}
+
+		if isSliceParam(field.typ) {
+			elem := field.typ
+			for elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+
+			param.Typed("array", "")
+			param.Items = spec.NewItems().Typed(paramType(elem.Elem()))
+			if field.in == inQuery {
+				param.CollectionFormat = "multi"
+			} else {
+				param.CollectionFormat = "csv"
+			}
+		} else {
+			param.Typed(paramType(field.typ))
+		}
+
+		if r := getRules(field.field); r != nil {
+			applyParamRules(&param, r, field.typ)
+		}
+		applyParamTags(&param, field.field, field.typ)
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// paramType returns the Swagger type and format of a bound parameter.
+func paramType(typ reflect.Type) (string, string) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == durationType:
+		return "string", "duration"
+	case typ == reflect.TypeFor[time.Time]():
+		return "string", "date-time"
+	case reflect.PointerTo(typ).Implements(textUnmarshalerType):
+		return "string", ""
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "integer", "int32"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer", "int64"
+	case reflect.Float32:
+		return "number", "float"
+	case reflect.Float64:
+		return "number", "double"
+	default:
+		return "string", ""
+	}
+}
+
+func describeResponse(op *spec.Operation, typ reflect.Type, structMap *structMap) {
+	if item, ok := seqItem(typ); ok {
+		describeStreamResponse(op, item, structMap)
+		return
+	}
+
+	res := spec.NewResponse().WithDescription(http.StatusText(http.StatusOK))
+
 	switch typ {
+	case nil, reflect.TypeFor[struct{}]():
+	case fileType:
+		describeFile(op)
+		return
+	case readerType:
+		op.Produces = []string{"application/octet-stream"}
+		res.WithSchema(new(spec.Schema).Typed("file", ""))
 	case reflect.TypeFor[[]byte]():
 		op.Produces = []string{"application/octet-stream"}
 	case reflect.TypeFor[string]():
 		op.Produces = []string{"text/plain"}
 	default:
 		op.Produces = []string{"application/json"}
-		res := getType(typ, structMap)
-		op.Responses = &spec.Responses{
-			ResponsesProps: spec.ResponsesProps{
-				Default: &spec.Response{
-					ResponseProps: spec.ResponseProps{
-						Schema: &res,
-					},
-				},
-			},
+		schema := getType(typ, structMap)
+		res.WithSchema(&schema)
+	}
+
+	op.RespondsWith(http.StatusOK, res)
+}
+
+// describeDeclaredResponses documents the responses declared with
+// WithResponse.
+func describeDeclaredResponses(op *spec.Operation, responses map[int]declaredResponse, structMap *structMap) {
+	for status, declared := range responses {
+		res := spec.NewResponse().WithDescription(declared.description)
+		if res.Description == "" {
+			res.Description = http.StatusText(status)
+		}
+
+		if declared.typ != reflect.TypeFor[struct{}]() {
+			schema := getType(declared.typ, structMap)
+			res.WithSchema(&schema)
+			if !contains(op.Produces, "application/json") {
+				op.Produces = append(op.Produces, "application/json")
+			}
 		}
+
+		op.RespondsWith(status, res)
+	}
+}
+
+// describeProblem documents Problem as the default response of op.
+func describeProblem(op *spec.Operation, structMap *structMap) {
+	schema := getStruct(reflect.TypeFor[Problem](), structMap)
+	op.WithDefaultResponse(spec.NewResponse().
+		WithDescription("Problem Details (RFC 9457)").
+		WithSchema(&schema))
+	op.Produces = append(op.Produces, "application/problem+json")
+}
+
+// nullable returns schema allowing null. Siblings of $ref are ignored, so
+// references are wrapped, as in applyTags.
+func nullable(schema spec.Schema) spec.Schema {
+	if schema.Ref.String() != "" {
+		schema = spec.Schema{SchemaProps: spec.SchemaProps{AllOf: []spec.Schema{schema}}}
 	}
+	schema.AddExtension("x-nullable", true)
+	return schema
 }
 
 // getType marshals a type into a spec.Schema.
 // structMap is the map of named structs which should be populated with any named structs encountered.
-func getType(typ reflect.Type, structMap structMap) spec.Schema {
+func getType(typ reflect.Type, structMap *structMap) spec.Schema {
+	if s, ok := implements[JSONSchemer](typ); ok {
+		return s.JSONSchema()
+	}
+	if schema, ok := knownSchema(typ); ok {
+		return schema
+	}
+	if schema, ok := oneOfSchema(typ, structMap); ok {
+		return schema
+	}
+	if value, ok := sqlNullValue(typ); ok && reflect.PointerTo(typ).Implements(jsonMarshalerType) {
+		return nullable(getType(value, structMap))
+	}
+
 	var schema spec.Schema
 
 	switch typ.Kind() {
@@ -247,11 +560,11 @@ func getType(typ reflect.Type, structMap structMap) spec.Schema {
 
 	case reflect.Float32:
 		schema.Type = []string{"number"}
-		schema.Schema = "float"
+		schema.Format = "float"
 
 	case reflect.Float64:
 		schema.Type = []string{"number"}
-		schema.Schema = "double"
+		schema.Format = "double"
 
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8,
 		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8, reflect.Uintptr:
@@ -264,9 +577,18 @@ func getType(typ reflect.Type, structMap structMap) spec.Schema {
 		return getStruct(typ, structMap)
 
 	case reflect.Pointer:
-		return getType(typ.Elem(), structMap)
+		// encoding/json encodes nil pointers as null
+		schema = nullable(getType(typ.Elem(), structMap))
+
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64
+			schema.Typed("string", "byte")
+			break
+		}
+		fallthrough
 
-	case reflect.Array, reflect.Slice:
+	case reflect.Array:
 		schema.Type = []string{"array"}
 		elem := getType(typ.Elem(), structMap)
 		schema.Items = &spec.SchemaOrArray{Schema: &elem}
@@ -277,17 +599,24 @@ func getType(typ reflect.Type, structMap structMap) spec.Schema {
 			elem := getType(typ.Elem(), structMap)
 			schema.AdditionalProperties = &spec.SchemaOrBool{Schema: &elem}
 		} else {
-			slog.Error("swagger: support only for maps with string keys; skipping", "type", typ.Name())
+			structMap.warnings.warn("swagger: support only for maps with string keys; skipping", "type", typ.String())
 		}
 
+	case reflect.Interface:
+		// Any value, described by a free-form schema
+
 	default:
-		slog.Error("swagger: unexpected reflect.Kind", "kind", typ.Kind())
+		structMap.warnings.warn("swagger: unexpected reflect.Kind", "kind", typ.Kind())
+	}
+
+	if d, ok := implements[Describer](typ); ok {
+		schema.Description = d.Describe()
 	}
 
 	return schema
 }
 
-func getStruct(typ reflect.Type, structMap structMap) spec.Schema {
+func getStruct(typ reflect.Type, structMap *structMap) spec.Schema {
 	// Just in case
 	if typ.Kind() != reflect.Struct {
 		panic("swagger: non-struct type passed to getStruct")
@@ -298,57 +627,61 @@ func getStruct(typ reflect.Type, structMap structMap) spec.Schema {
 	}
 
 	// return ref if found
-	if _, ok := structMap[typ]; ok {
-		return ref(typ)
+	if _, ok := structMap.schemas[typ]; ok {
+		return structMap.ref(typ)
+	}
+
+	// Register named types before walking their fields, so that fields
+	// referring back to a type being built get a reference to it
+	if typ.Name() != "" {
+		structMap.schemas[typ] = spec.Schema{}
 	}
 
 	var schema spec.Schema
 	schema.Type = []string{"object"}
 	schema.Properties = make(spec.SchemaProperties)
 
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-		name, required := fieldName(field)
+	for _, f := range jsonFields(typ) {
+		field := f.field
+		if _, _, ok := paramTag(field); ok {
+			// Bound to a parameter, not part of the body
+			continue
+		}
+		// encoding/json always writes non-optional fields, but nil pointers
+		// may be left out by clients
+		required := !f.optional && field.Type.Kind() != reflect.Pointer
+
+		var prop spec.Schema
+		if f.quoted {
+			prop = *spec.StringProperty()
+		} else {
+			prop = getType(field.Type, structMap)
+		}
+		if r := getRules(field); r != nil {
+			required = required || r.required
+			// Constraints can't be placed next to a $ref
+			if prop.Ref.String() == "" && !f.quoted {
+				applyRules(&prop, r, field.Type)
+			}
+		}
+		prop = applyTags(prop, field)
 
-		schema.Properties[name] = getType(field.Type, structMap)
+		schema.Properties[f.name] = prop
 		if required {
-			schema.Required = append(schema.Required, name)
+			schema.Required = append(schema.Required, f.name)
 		}
 	}
 
+	if d, ok := implements[Describer](typ); ok {
+		schema.Description = d.Describe()
+	}
+
 	// If the type is inline return the schema itself
 	if typ.Name() == "" {
 		return schema
 	}
 
 	// Otherwise, return a reference
-	structMap[typ] = schema
-	return ref(typ)
-}
-
-func ref(typ reflect.Type) spec.Schema {
-	return spec.Schema{
-		SchemaProps: spec.SchemaProps{
-			Ref: spec.MustCreateRef("#/definitions/" + typ.Name()),
-		},
-	}
-}
-
-func fieldName(field reflect.StructField) (name string, required bool) {
-	name = field.Name
-	if field.Anonymous {
-		name = field.Type.Name()
-	}
-
-	tag := field.Tag.Get("json")
-	if tag == "" {
-		return
-	}
-
-	tagName, omitempty, _ := strings.Cut(tag, ",")
-	if tagName != "" {
-		name = tagName
-	}
-
-	return name, omitempty != "omitempty"
+	structMap.schemas[typ] = schema
+	return structMap.ref(typ)
 }
@@ -1,7 +1,13 @@
 package pf
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+
+	"github.com/go-openapi/spec"
 )
 
 type TestRequest struct {
@@ -32,16 +38,127 @@ func TestGenerateSpec(t *testing.T) {
 	r := NewRouter()
 	Post(r, "/get", Ping)
 
-	bytes, err := generateSpec(
-		r.traverseSignatures(),
+	bytes, err := must(generateSpec(
+		r,
 		&SwaggerInfo{
 			Title:   "PABLO",
 			Version: "v0.0.0.0.0.0.0.1",
 		},
-	).MarshalJSON()
+		nil,
+	)).MarshalJSON()
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	t.Log(string(bytes))
 }
+
+func TestGroupProps(t *testing.T) {
+	r := NewRouter()
+	Route(r, "/orders", func(r *Router) {
+		Get(r, "/", Ping, WithTags("list"))
+		Put(r, "/{id}", func(w ResponseWriter[struct{}], r *Request[BindRequest]) error {
+			return w.Reply(409, ConflictBody{})
+		})
+	}, WithTags("orders"), WithDeprecated(), WithResponse[ConflictBody](409, "Conflict"))
+
+	admin := NewRouter()
+	Get(admin, "/stats", Ping, WithTags("orders"))
+	Mount(r, "/admin", admin, WithTags("admin"), WithProduces("application/json", "text/csv"))
+
+	s := must(generateSpec(r, &SwaggerInfo{
+		Tags: []Tag{{Name: "orders", Description: "Order management", ExternalDocsURL: "https://example.com"}},
+	}, nil))
+
+	if len(s.Tags) != 1 || s.Tags[0].ExternalDocs.URL != "https://example.com" {
+		t.Errorf("tags = %+v", s.Tags)
+	}
+
+	list := s.Paths.Paths["/orders/"].Get
+	if !reflect.DeepEqual(list.Tags, []string{"orders", "list"}) || !list.Deprecated {
+		t.Errorf("list tags = %v, deprecated = %v", list.Tags, list.Deprecated)
+	}
+	if _, ok := list.Responses.StatusCodeResponses[409]; !ok {
+		t.Errorf("list responses = %v", list.Responses.StatusCodeResponses)
+	}
+
+	stats := s.Paths.Paths["/admin/stats"].Get
+	if !reflect.DeepEqual(stats.Tags, []string{"admin", "orders"}) || len(stats.Produces) != 2 {
+		t.Errorf("stats tags = %v, produces = %v", stats.Tags, stats.Produces)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/orders/1", nil))
+	if rec.Code != 409 {
+		t.Errorf("status = %d, want 409", rec.Code)
+	}
+}
+
+func TestFloatFormats(t *testing.T) {
+	for typ, want := range map[reflect.Type]string{
+		reflect.TypeFor[float32](): "float",
+		reflect.TypeFor[float64](): "double",
+	} {
+		schema := getType(typ, newStructMap(nil))
+		if schema.Format != want || schema.Schema != "" {
+			t.Errorf("%v: format = %q, $schema = %q", typ, schema.Format, schema.Schema)
+		}
+	}
+}
+
+type Category struct {
+	Name     string      `json:"name"`
+	Children []*Category `json:"children"`
+}
+
+type Thread struct {
+	Comments []Comment `json:"comments"`
+}
+
+type Comment struct {
+	Author  Author  `json:"author"`
+	Replies *Thread `json:"replies"`
+}
+
+type Author struct {
+	Pinned *Comment `json:"pinned"`
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	r := NewRouter()
+	Get(r, "/categories", func(w ResponseWriter[Category], r *Request[struct{}]) error {
+		return nil
+	})
+	Post(r, "/threads", func(w ResponseWriter[Thread], r *Request[Comment]) error {
+		return nil
+	})
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+
+	if author := s.Definitions["Comment"].Properties["author"]; author.Ref.String() != "#/definitions/Author" {
+		t.Errorf("Comment.author = %+v", author)
+	}
+	// Pointers are nullable, with the reference wrapped as siblings of $ref
+	// are ignored
+	refs := map[string]spec.Schema{
+		"Category.children": *s.Definitions["Category"].Properties["children"].Items.Schema,
+		"Comment.replies":   s.Definitions["Comment"].Properties["replies"],
+		"Author.pinned":     s.Definitions["Author"].Properties["pinned"],
+	}
+	want := map[string]string{
+		"Category.children": "#/definitions/Category",
+		"Comment.replies":   "#/definitions/Thread",
+		"Author.pinned":     "#/definitions/Comment",
+	}
+	for path, schema := range refs {
+		if schema.Ref.String() != "" || len(schema.AllOf) != 1 || schema.AllOf[0].Ref.String() != want[path] || schema.Extensions["x-nullable"] != true {
+			t.Errorf("%s = %+v", path, schema)
+		}
+	}
+
+	doc := must(generateOpenAPI(r, new(SwaggerInfo), nil))
+	replies := doc.Components.Schemas["Comment"]["properties"].(map[string]any)["replies"]
+	if got := must(json.Marshal(replies)); string(got) != `{"anyOf":[{"$ref":"#/components/schemas/Thread"},{"type":"null"}]}` {
+		t.Errorf("replies = %s", got)
+	}
+}
@@ -0,0 +1,118 @@
+// Code generated by pf. DO NOT EDIT.
+
+export interface TestRequest {
+  A: {
+    Name: string;
+  };
+  B?: TestRequestField | null;
+  C: number;
+}
+
+export interface TestRequestField {
+  Desc: string;
+}
+
+export interface ValidatedItem {
+  name: string;
+}
+
+/** ApiError is thrown for error responses, with the decoded body. */
+export class ApiError extends Error {
+  constructor(
+    public status: number,
+    public body: unknown,
+  ) {
+    super(`HTTP ${status}`);
+  }
+}
+
+export interface ClientOptions {
+  baseUrl: string;
+  fetch?: typeof fetch;
+  headers?: Record<string, string>;
+}
+
+type Params = Record<string, unknown>;
+
+export class Client {
+  constructor(private options: ClientOptions) {}
+
+  private async request(
+    method: string,
+    path: string,
+    query: Params,
+    headers: Params,
+    body: unknown,
+    accept: "json" | "text" | "blob" | "none",
+  ): Promise<any> {
+    const search = new URLSearchParams();
+    for (const [key, value] of Object.entries(query)) {
+      for (const item of Array.isArray(value) ? value : [value]) {
+        if (item !== undefined && item !== null) search.append(key, String(item));
+      }
+    }
+    const init: RequestInit = { method, headers: { ...this.options.headers } };
+    const hdrs = init.headers as Record<string, string>;
+    for (const [key, value] of Object.entries(headers)) {
+      if (value !== undefined && value !== null) hdrs[key] = Array.isArray(value) ? value.join(",") : String(value);
+    }
+    if (body instanceof Blob) {
+      init.body = body;
+    } else if (body !== undefined) {
+      hdrs["Content-Type"] = "application/json";
+      init.body = JSON.stringify(body);
+    }
+
+    const qs = search.toString();
+    const url = this.options.baseUrl.replace(/\/$/, "") + path + (qs ? "?" + qs : "");
+    const res = await (this.options.fetch ?? fetch)(url, init);
+    if (!res.ok) {
+      const text = await res.text();
+      let decoded: unknown = text;
+      if (/json/.test(res.headers.get("Content-Type") ?? "")) {
+        try {
+          decoded = JSON.parse(text);
+        } catch {}
+      }
+      throw new ApiError(res.status, decoded);
+    }
+
+    switch (accept) {
+      case "json":
+        return res.status === 204 ? undefined : res.json();
+      case "text":
+        return res.text();
+      case "blob":
+        return res.blob();
+      default:
+        return undefined;
+    }
+  }
+
+  /** GET /files/{type}/{*} */
+  getFilesType(params: {
+    type: string;
+    /** The rest of the path */
+    "*": string;
+  }): Promise<Blob> {
+    return this.request("GET", "/files/{type}/{*}".replace("{type}", encodeURIComponent(String(params["type"]))).replace("{*}", String(params["*"])), {}, {}, undefined, "blob");
+  }
+
+  /** GET /items/{id} */
+  getItemsId(params: {
+    id: number;
+    limit?: number;
+    tag?: string[];
+    since?: string;
+    wait?: string;
+    "X-Tenant"?: string;
+    "X-Flags"?: boolean[];
+  }): Promise<ValidatedItem[]> {
+    return this.request("GET", "/items/{id}".replace("{id}", encodeURIComponent(String(params["id"]))), { limit: params["limit"], tag: params["tag"], since: params["since"], wait: params["wait"] }, { "X-Tenant": params["X-Tenant"], "X-Flags": params["X-Flags"] }, undefined, "json");
+  }
+
+  /** Uploads beer */
+  postWatUploadbeer(body: TestRequest): Promise<void> {
+    return this.request("POST", "/wat/uploadbeer", {}, {}, body, "none");
+  }
+}
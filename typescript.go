@@ -0,0 +1,396 @@
+package pf
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// GenerateTypeScript generates a TypeScript module with an interface per
+// definition of the spec of r and a fetch-based Client with a method per
+// operation, such as getUsersId for GET /users/{id}. Methods take the
+// parameters in an object and the request body, and throw an ApiError for
+// error responses.
+func GenerateTypeScript(r *Router) ([]byte, error) {
+	info := r.info
+	if info == nil {
+		info = new(SwaggerInfo)
+	}
+	s, err := buildSpec(r, info, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g := tsGen{names: make(map[string]string)}
+	taken := make(map[string]string)
+	for _, def := range sortedKeys(s.Definitions, nil) {
+		name := goIdent(def)
+		if other, ok := taken[name]; ok {
+			return nil, fmt.Errorf("pf: definitions %s and %s have the same TypeScript name %s", other, def, name)
+		}
+		taken[name] = def
+		g.names[def] = name
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by pf. DO NOT EDIT.\n")
+	for _, def := range sortedKeys(s.Definitions, nil) {
+		g.writeInterface(&b, g.names[def], s.Definitions[def])
+	}
+	b.WriteString(tsRuntime)
+
+	methods := make(map[string]string)
+	for _, path := range sortedKeys(s.Paths.Paths, nil) {
+		ops := operationsByMethod(s.Paths.Paths[path])
+		for _, method := range sortedKeys(ops, nil) {
+			name := goIdent(strings.ToLower(method) + " " + path)
+			name = strings.ToLower(name[:1]) + name[1:]
+			if prev, ok := methods[name]; ok {
+				return nil, fmt.Errorf("pf: operations %s and %s have the same TypeScript method name %s", prev, method+" "+path, name)
+			}
+			methods[name] = method + " " + path
+			g.writeMethod(&b, name, method, path, ops[method])
+		}
+	}
+	b.WriteString("}\n")
+
+	return b.Bytes(), nil
+}
+
+// tsRuntime is the part of generated modules independent of the router.
+const tsRuntime = `
+/** ApiError is thrown for error responses, with the decoded body. */
+export class ApiError extends Error {
+  constructor(
+    public status: number,
+    public body: unknown,
+  ) {
+    super(` + "`HTTP ${status}`" + `);
+  }
+}
+
+export interface ClientOptions {
+  baseUrl: string;
+  fetch?: typeof fetch;
+  headers?: Record<string, string>;
+}
+
+type Params = Record<string, unknown>;
+
+export class Client {
+  constructor(private options: ClientOptions) {}
+
+  private async request(
+    method: string,
+    path: string,
+    query: Params,
+    headers: Params,
+    body: unknown,
+    accept: "json" | "text" | "blob" | "none",
+  ): Promise<any> {
+    const search = new URLSearchParams();
+    for (const [key, value] of Object.entries(query)) {
+      for (const item of Array.isArray(value) ? value : [value]) {
+        if (item !== undefined && item !== null) search.append(key, String(item));
+      }
+    }
+    const init: RequestInit = { method, headers: { ...this.options.headers } };
+    const hdrs = init.headers as Record<string, string>;
+    for (const [key, value] of Object.entries(headers)) {
+      if (value !== undefined && value !== null) hdrs[key] = Array.isArray(value) ? value.join(",") : String(value);
+    }
+    if (body instanceof Blob) {
+      init.body = body;
+    } else if (body !== undefined) {
+      hdrs["Content-Type"] = "application/json";
+      init.body = JSON.stringify(body);
+    }
+
+    const qs = search.toString();
+    const url = this.options.baseUrl.replace(/\/$/, "") + path + (qs ? "?" + qs : "");
+    const res = await (this.options.fetch ?? fetch)(url, init);
+    if (!res.ok) {
+      const text = await res.text();
+      let decoded: unknown = text;
+      if (/json/.test(res.headers.get("Content-Type") ?? "")) {
+        try {
+          decoded = JSON.parse(text);
+        } catch {}
+      }
+      throw new ApiError(res.status, decoded);
+    }
+
+    switch (accept) {
+      case "json":
+        return res.status === 204 ? undefined : res.json();
+      case "text":
+        return res.text();
+      case "blob":
+        return res.blob();
+      default:
+        return undefined;
+    }
+  }
+`
+
+type tsGen struct {
+	// names maps definitions to TypeScript names.
+	names map[string]string
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// tsKey returns name as a key of an object type.
+func tsKey(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// tsDoc writes the description of a member as a JSDoc comment.
+func tsDoc(b *bytes.Buffer, indent string, schema spec.Schema) {
+	var lines []string
+	if schema.Description != "" {
+		lines = strings.Split(schema.Description, "\n")
+	}
+	if schema.Extensions["x-deprecated"] == true {
+		lines = append(lines, "@deprecated")
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(lines[0], "*/", "*\\/"))
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, strings.ReplaceAll(line, "*/", "*\\/"))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+func (g *tsGen) writeInterface(b *bytes.Buffer, name string, schema spec.Schema) {
+	b.WriteString("\n")
+	tsDoc(b, "", schema)
+	if len(schema.Properties) == 0 && (len(schema.Type) != 1 || schema.Type[0] != "object") {
+		fmt.Fprintf(b, "export type %s = %s;\n", name, g.typ(schema, ""))
+		return
+	}
+	fmt.Fprintf(b, "export interface %s %s\n", name, g.object(schema, ""))
+}
+
+// object returns the object type of schema.
+func (g *tsGen) object(schema spec.Schema, indent string) string {
+	var b bytes.Buffer
+	b.WriteString("{\n")
+	for _, name := range sortedKeys(schema.Properties, nil) {
+		prop := schema.Properties[name]
+		optional := "?"
+		if slices.Contains(schema.Required, name) {
+			optional = ""
+		}
+		tsDoc(&b, indent+"  ", prop)
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsKey(name), optional, g.typ(prop, indent+"  "))
+	}
+	if ap := schema.AdditionalProperties; ap != nil && ap.Schema != nil {
+		fmt.Fprintf(&b, "%s  [key: string]: %s;\n", indent, g.typ(*ap.Schema, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// typ returns the TypeScript type of schema.
+func (g *tsGen) typ(schema spec.Schema, indent string) string {
+	t := g.baseType(schema, indent)
+	if schema.Extensions["x-nullable"] == true {
+		t += " | null"
+	}
+	return t
+}
+
+func (g *tsGen) baseType(schema spec.Schema, indent string) string {
+	if len(schema.AllOf) == 1 && len(schema.Type) == 0 {
+		return g.baseType(schema.AllOf[0], indent)
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		if name, ok := g.names[strings.TrimPrefix(ref, "#/definitions/")]; ok {
+			return name
+		}
+		return "unknown"
+	}
+	if len(schema.OneOf) > 0 {
+		variants := make([]string, len(schema.OneOf))
+		for i, variant := range schema.OneOf {
+			variants[i] = g.typ(variant, indent)
+		}
+		return strings.Join(variants, " | ")
+	}
+
+	if len(schema.Enum) > 0 {
+		values := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			if s, ok := v.(string); ok {
+				values[i] = strconv.Quote(s)
+			} else {
+				values[i] = fmt.Sprint(v)
+			}
+		}
+		return strings.Join(values, " | ")
+	}
+
+	if len(schema.Type) == 0 {
+		return "unknown"
+	}
+	switch schema.Type[0] {
+	case "string":
+		if schema.Format == "binary" {
+			return "Blob"
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "file":
+		return "Blob"
+	case "array":
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return "unknown[]"
+		}
+		items := g.typ(*schema.Items.Schema, indent)
+		if strings.Contains(items, " ") {
+			items = "(" + items + ")"
+		}
+		return items + "[]"
+	case "object":
+		if len(schema.Properties) == 0 {
+			if ap := schema.AdditionalProperties; ap != nil && ap.Schema != nil {
+				return "Record<string, " + g.typ(*ap.Schema, indent) + ">"
+			}
+			return "Record<string, unknown>"
+		}
+		return g.object(schema, indent)
+	default:
+		return "unknown"
+	}
+}
+
+// paramType returns the TypeScript type of a non-body parameter.
+func (g *tsGen) paramType(p spec.Parameter) string {
+	return g.typ(paramSchemaOf(p), "")
+}
+
+func (g *tsGen) writeMethod(b *bytes.Buffer, name, method, path string, op *spec.Operation) {
+	if _, ws := op.Extensions["x-websocket"]; ws || slices.Contains(op.Produces, "text/event-stream") || slices.Contains(op.Produces, "application/x-ndjson") {
+		fmt.Fprintf(b, "\n  // %s is not generated: streaming endpoints are not supported.\n", name)
+		return
+	}
+
+	var args, query, headers []string
+	var params bytes.Buffer
+	required := false
+	body := "undefined"
+	urlPath := strconv.Quote(path)
+
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "body":
+			t := g.typ(*schemaOrEmpty(p.Schema), "  ")
+			if p.Required {
+				args = append(args, "body: "+t)
+			} else {
+				args = append(args, "body?: "+t)
+			}
+			body = "body"
+			continue
+		case inPath:
+			urlPath = fmt.Sprintf("%s.replace(%q, encodeURIComponent(String(params[%q])))", urlPath, "{"+p.Name+"}", p.Name)
+			if p.Name == wildcardParam {
+				urlPath = strings.Replace(urlPath, fmt.Sprintf("encodeURIComponent(String(params[%q]))", p.Name), fmt.Sprintf("String(params[%q])", p.Name), 1)
+			}
+		case inQuery:
+			query = append(query, fmt.Sprintf("%s: params[%q]", tsKey(p.Name), p.Name))
+		case inHeader:
+			headers = append(headers, fmt.Sprintf("%s: params[%q]", tsKey(p.Name), p.Name))
+		default:
+			continue
+		}
+
+		optional := "?"
+		if p.Required {
+			optional = ""
+			required = true
+		}
+		tsDoc(&params, "    ", spec.Schema{SchemaProps: spec.SchemaProps{Description: p.Description}})
+		fmt.Fprintf(&params, "    %s%s: %s;\n", tsKey(p.Name), optional, g.paramType(p))
+	}
+	if params.Len() > 0 {
+		arg := "params"
+		if !required {
+			arg += "?"
+		}
+		args = append([]string{arg + ": {\n" + params.String() + "  }"}, args...)
+		if !required {
+			// Optional parameters are read from an empty object
+			urlPath = strings.ReplaceAll(urlPath, "params[", "(params ?? {})[")
+			for i := range query {
+				query[i] = strings.Replace(query[i], "params[", "(params ?? {})[", 1)
+			}
+			for i := range headers {
+				headers[i] = strings.Replace(headers[i], "params[", "(params ?? {})[", 1)
+			}
+		}
+	}
+
+	res, accept := "void", "none"
+	if op.Responses != nil {
+		if ok, found := op.Responses.StatusCodeResponses[http.StatusOK]; found {
+			switch {
+			case slices.Contains(op.Produces, "application/octet-stream"):
+				res, accept = "Blob", "blob"
+			case slices.Contains(op.Produces, "text/plain"):
+				res, accept = "string", "text"
+			case ok.Schema != nil:
+				res, accept = g.typ(*ok.Schema, "  "), "json"
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	summary := op.Summary
+	if summary == "" {
+		summary = method + " " + path
+	}
+	tsDoc(b, "  ", spec.Schema{
+		SchemaProps:      spec.SchemaProps{Description: summary},
+		VendorExtensible: spec.VendorExtensible{Extensions: deprecatedExtension(op.Deprecated)},
+	})
+	fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), res)
+	fmt.Fprintf(b, "    return this.request(%q, %s, %s, %s, %s, %q);\n",
+		method, urlPath, tsObject(query), tsObject(headers), body, accept)
+	b.WriteString("  }\n")
+}
+
+// tsObject returns an object literal of the entries.
+func tsObject(entries []string) string {
+	if len(entries) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(entries, ", ") + " }"
+}
+
+func deprecatedExtension(deprecated bool) spec.Extensions {
+	if !deprecated {
+		return nil
+	}
+	return spec.Extensions{"x-deprecated": true}
+}
@@ -0,0 +1,475 @@
+package pf
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go-openapi/spec"
+)
+
+// FieldError describes a single field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a request fails validation. It is reported
+// to the client as 422 Unprocessable Entity along with the failing fields.
+type ValidationError struct {
+	Fields []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		if f.Field == "" {
+			msgs[i] = f.Message
+		} else {
+			msgs[i] = f.Field + " " + f.Message
+		}
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrUnprocessableEntity
+}
+
+// Validator is implemented by request types that check themselves beyond
+// what the validate struct tags express. Validate is called after the tag
+// constraints are satisfied. A returned ValidationError or error defined in
+// this package is responded with as is; any other error is reported as a 422
+// with its message.
+type Validator interface {
+	Validate() error
+}
+
+// rules are the constraints parsed from a `validate:"..."` struct tag.
+type rules struct {
+	required bool
+	min, max *float64
+	email    bool
+	oneof    []string
+	pattern  *regexp.Regexp
+}
+
+var parsedRules sync.Map // map[string]*rules
+
+// getRules returns the rules of field, or nil if it has none. getRules panics
+// if the tag is malformed.
+func getRules(field reflect.StructField) *rules {
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+
+	if r, ok := parsedRules.Load(tag); ok {
+		return r.(*rules)
+	}
+
+	r, err := parseRules(tag)
+	if err != nil {
+		panic(fmt.Sprintf("pf: invalid validate tag on field %s: %v", field.Name, err))
+	}
+
+	parsedRules.Store(tag, r)
+	return r
+}
+
+func parseRules(tag string) (*rules, error) {
+	r := new(rules)
+
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			r.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if name == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "email":
+			r.email = true
+		case "oneof":
+			r.oneof = strings.Fields(arg)
+		case "regex":
+			pattern, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("regex: %w", err)
+			}
+			r.pattern = pattern
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+
+	return r, nil
+}
+
+// check appends the violations of r by v to errs.
+func (r *rules) check(v reflect.Value, field string, errs []FieldError) []FieldError {
+	fail := func(rule, format string, args ...any) {
+		errs = append(errs, FieldError{field, rule, fmt.Sprintf(format, args...)})
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if r.required {
+				fail("required", "is required")
+			}
+			return errs
+		}
+		v = v.Elem()
+	}
+
+	if r.required && isEmpty(v) {
+		fail("required", "is required")
+		return errs
+	}
+
+	size, unit, ok := measure(v)
+	if ok && r.min != nil && size < *r.min {
+		fail("min", "must be at least %v%s", *r.min, unit)
+	}
+	if ok && r.max != nil && size > *r.max {
+		fail("max", "must be at most %v%s", *r.max, unit)
+	}
+
+	// Format rules don't apply to optional empty strings
+	if v.Kind() == reflect.String && v.Len() == 0 && !r.required {
+		return errs
+	}
+
+	if r.email && v.Kind() == reflect.String {
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			fail("email", "must be a valid email address")
+		}
+	}
+
+	if r.oneof != nil {
+		s := fmt.Sprint(v.Interface())
+		found := false
+		for _, option := range r.oneof {
+			if s == option {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("oneof", "must be one of: %s", strings.Join(r.oneof, ", "))
+		}
+	}
+
+	if r.pattern != nil && v.Kind() == reflect.String && !r.pattern.MatchString(v.String()) {
+		fail("regex", "must match %s", r.pattern)
+	}
+
+	return errs
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// measure returns the value compared against min and max: the number itself,
+// or the length of strings and collections.
+func measure(v reflect.Value) (size float64, unit string, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters long", true
+	case reflect.Slice, reflect.Array:
+		return float64(v.Len()), " items", true
+	case reflect.Map:
+		return float64(v.Len()), " entries", true
+	default:
+		return 0, "", false
+	}
+}
+
+// validator checks values of a type against the rules of its fields and
+// nested types.
+type validator struct {
+	fields []fieldValidator // for structs
+	elem   *validator       // for pointers, slices, arrays and maps
+}
+
+type fieldValidator struct {
+	index int
+	name  string
+	rules *rules
+	typ   *validator
+}
+
+var (
+	validatorsMu sync.Mutex
+	validators   = make(map[reflect.Type]*validator)
+)
+
+// getValidator returns the validator of typ, or nil if values of typ need no
+// validation.
+func getValidator(typ reflect.Type) *validator {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	return buildValidator(typ)
+}
+
+func buildValidator(typ reflect.Type) *validator {
+	if v, ok := validators[typ]; ok {
+		return v
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		// Register before descending so that recursive types terminate
+		v := new(validator)
+		validators[typ] = v
+		if v.elem = buildValidator(typ.Elem()); v.elem == nil {
+			validators[typ] = nil
+			return nil
+		}
+		return v
+
+	case reflect.Struct:
+		v := new(validator)
+		validators[typ] = v
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fv := fieldValidator{
+				index: i,
+				rules: getRules(field),
+				typ:   buildValidator(field.Type),
+			}
+			if fv.rules == nil && fv.typ == nil {
+				continue
+			}
+
+			if _, name, ok := paramTag(field); ok {
+				fv.name = name
+			} else if field.Anonymous {
+				fv.name = ""
+			} else {
+				fv.name = jsonName(field)
+			}
+			v.fields = append(v.fields, fv)
+		}
+
+		if len(v.fields) == 0 {
+			validators[typ] = nil
+			return nil
+		}
+		return v
+
+	default:
+		return nil
+	}
+}
+
+func (v *validator) check(val reflect.Value, path string, errs []FieldError) []FieldError {
+	switch val.Kind() {
+	case reflect.Pointer:
+		if !val.IsNil() {
+			errs = v.elem.check(val.Elem(), path, errs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			errs = v.elem.check(val.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			errs = v.elem.check(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), errs)
+		}
+
+	case reflect.Struct:
+		for _, f := range v.fields {
+			field := val.Field(f.index)
+			name := joinPath(path, f.name)
+
+			if f.rules != nil {
+				errs = f.rules.check(field, name, errs)
+			}
+			if f.typ != nil {
+				errs = f.typ.check(field, name, errs)
+			}
+		}
+	}
+
+	return errs
+}
+
+func joinPath(path, name string) string {
+	switch {
+	case path == "":
+		return name
+	case name == "":
+		return path
+	default:
+		return path + "." + name
+	}
+}
+
+// validate checks body against its validate struct tags and, if it implements
+// Validator, its Validate method.
+func validate[T any](body *T) error {
+	if v := getValidator(reflect.TypeFor[T]()); v != nil {
+		errs := v.check(reflect.ValueOf(body).Elem(), "", nil)
+		if len(errs) > 0 {
+			return &ValidationError{Fields: errs}
+		}
+	}
+
+	validator, ok := any(body).(Validator)
+	if !ok {
+		validator, ok = any(*body).(Validator)
+	}
+	if !ok {
+		return nil
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *ValidationError
+	var httpErr httpError
+	if errors.As(err, &validationErr) || errors.As(err, &httpErr) {
+		return err
+	}
+
+	return &ValidationError{Fields: []FieldError{{Rule: "validate", Message: err.Error()}}}
+}
+
+// applyRules adds the constraints of r to the schema of a property of type
+// typ.
+func applyRules(schema *spec.Schema, r *rules, typ reflect.Type) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		if r.min != nil {
+			schema.MinLength = toInt64(*r.min)
+		}
+		if r.max != nil {
+			schema.MaxLength = toInt64(*r.max)
+		}
+	case reflect.Slice, reflect.Array:
+		if r.min != nil {
+			schema.MinItems = toInt64(*r.min)
+		}
+		if r.max != nil {
+			schema.MaxItems = toInt64(*r.max)
+		}
+	case reflect.Map:
+		if r.min != nil {
+			schema.MinProperties = toInt64(*r.min)
+		}
+		if r.max != nil {
+			schema.MaxProperties = toInt64(*r.max)
+		}
+	default:
+		schema.Minimum = r.min
+		schema.Maximum = r.max
+	}
+
+	if r.email {
+		schema.Format = "email"
+	}
+	if r.pattern != nil {
+		schema.Pattern = r.pattern.String()
+	}
+	if r.oneof != nil {
+		schema.Enum = enumValues(r.oneof, typ)
+	}
+}
+
+// applyParamRules adds the constraints of r to a parameter of type typ.
+func applyParamRules(param *spec.Parameter, r *rules, typ reflect.Type) {
+	var schema spec.Schema
+	applyRules(&schema, r, typ)
+
+	param.Required = param.Required || r.required
+	param.Minimum = schema.Minimum
+	param.Maximum = schema.Maximum
+	param.MinLength = schema.MinLength
+	param.MaxLength = schema.MaxLength
+	param.MinItems = schema.MinItems
+	param.MaxItems = schema.MaxItems
+	param.Pattern = schema.Pattern
+	param.Enum = schema.Enum
+	if schema.Format != "" {
+		param.Format = schema.Format
+	}
+}
+
+func toInt64(f float64) *int64 {
+	n := int64(f)
+	return &n
+}
+
+// enumValues converts the oneof options to values of the kind of typ.
+func enumValues(options []string, typ reflect.Type) []interface{} {
+	values := make([]interface{}, len(options))
+	for i, option := range options {
+		values[i] = option
+
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n, err := strconv.ParseInt(option, 10, 64); err == nil {
+				values[i] = n
+			}
+		case reflect.Float32, reflect.Float64:
+			if f, err := strconv.ParseFloat(option, 64); err == nil {
+				values[i] = f
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(option); err == nil {
+				values[i] = b
+			}
+		}
+	}
+	return values
+}
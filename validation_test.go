@@ -0,0 +1,87 @@
+package pf
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type ValidatedItem struct {
+	Name string `json:"name" validate:"required,regex=^[a-z]+$"`
+}
+
+type ValidatedRequest struct {
+	Email  string          `json:"email" validate:"required,email"`
+	Volume int             `json:"volume" validate:"min=1,max=100"`
+	Kind   string          `json:"kind,omitempty" validate:"oneof=ale lager"`
+	Items  []ValidatedItem `json:"items" validate:"max=2"`
+	Limit  int             `query:"limit" validate:"max=50"`
+}
+
+func (r ValidatedRequest) Validate() error {
+	if r.Kind == "lager" && r.Volume > 10 {
+		return errors.New("too much lager")
+	}
+	return nil
+}
+
+func TestValidation(t *testing.T) {
+	r := NewRouter()
+	Post(r, "/beer", func(w ResponseWriter[struct{}], r *Request[ValidatedRequest]) error {
+		return nil
+	})
+
+	tests := []struct {
+		body   string
+		query  string
+		status int
+		fields []string
+	}{
+		{`{"email":"a@b.c","volume":5,"items":[{"name":"ok"}]}`, "", http.StatusOK, nil},
+		{`{"email":"nope","volume":0,"kind":"stout"}`, "?limit=51", http.StatusUnprocessableEntity, []string{"email", "volume", "kind", "limit"}},
+		{`{"email":"a@b.c","volume":5,"items":[{"name":"A1"},{},{}]}`, "", http.StatusUnprocessableEntity, []string{"items", "items[0].name", "items[1].name", "items[2].name"}},
+		{`{"email":"a@b.c","volume":20,"kind":"lager"}`, "", http.StatusUnprocessableEntity, []string{""}},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/beer"+test.query, strings.NewReader(test.body)))
+		if rec.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.body, rec.Code, test.status)
+			continue
+		}
+		if test.fields == nil {
+			continue
+		}
+
+		var res ValidationError
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		var fields []string
+		for _, f := range res.Fields {
+			fields = append(fields, f.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(test.fields, ",") {
+			t.Errorf("%s: fields = %v, want %v", test.body, fields, test.fields)
+		}
+	}
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+	def := s.Definitions["ValidatedRequest"]
+	if v := def.Properties["volume"]; *v.Minimum != 1 || *v.Maximum != 100 {
+		t.Errorf("volume = %+v", v.SchemaProps)
+	}
+	if v := def.Properties["email"]; v.Format != "email" || len(def.Required) == 0 || def.Required[0] != "email" {
+		t.Errorf("email = %+v, required = %v", v.SchemaProps, def.Required)
+	}
+	if v := def.Properties["kind"]; len(v.Enum) != 2 {
+		t.Errorf("kind = %+v", v.SchemaProps)
+	}
+	if v := s.Definitions["ValidatedItem"].Properties["name"]; v.Pattern != "^[a-z]+$" {
+		t.Errorf("name = %+v", v.SchemaProps)
+	}
+}
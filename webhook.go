@@ -0,0 +1,60 @@
+package pf
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-openapi/spec"
+)
+
+// webhooksExtension is the extension of the spec built by buildSpec holding
+// the webhooks, which only OpenAPI 3.1 documents.
+const webhooksExtension = "x-webhooks"
+
+// Webhook documents the webhook name, a POST request with a body of type Event
+// that the API sends to URLs registered by its clients, in the OpenAPI 3.1
+// spec. props document the request like those of handlers, but the props of
+// the groups of r are not applied. Swagger 2.0 specs don't document webhooks.
+func Webhook[Event any](r *Router, name string, props ...HandlerProperty) {
+	if r.webhooks == nil {
+		r.webhooks = make(signatures)
+	}
+	r.webhooks.add(name, http.MethodPost, &handlerSignature{
+		reqType: reflect.TypeFor[Event](),
+		resType: reflect.TypeFor[struct{}](),
+		props:   props,
+	})
+}
+
+func (r *Router) traverseWebhooks() signatures {
+	out := make(signatures)
+	for name, methods := range r.webhooks {
+		out[name] = methods
+	}
+	for _, subrouter := range r.subrouters {
+		for name, methods := range subrouter.traverseWebhooks() {
+			out[name] = methods
+		}
+	}
+	return out
+}
+
+// createWebhooks documents the webhooks of r in s.
+func createWebhooks(s *spec.Swagger, r *Router, structMap *structMap) {
+	hooks := r.traverseWebhooks()
+	if len(hooks) == 0 {
+		return
+	}
+
+	items := make(map[string]spec.PathItem, len(hooks))
+	for name, methods := range hooks {
+		items[name] = createPathItem(spec.PathItem{}, methods, nil, structMap)
+	}
+	s.AddExtension(webhooksExtension, items)
+}
+
+// webhooks returns the webhooks documented in s by createWebhooks.
+func webhooks(s *spec.Swagger) map[string]spec.PathItem {
+	items, _ := s.Extensions[webhooksExtension].(map[string]spec.PathItem)
+	return items
+}
@@ -0,0 +1,340 @@
+package pf
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coder/websocket"
+	"github.com/go-openapi/spec"
+)
+
+// WebSocketHandler exchanges messages with the client of a WebSocket
+// connection, receiving messages of type In and sending messages of type Out.
+// The handshake request is parsed into Req and validated like for Handler
+// before the connection is upgraded. The connection is closed when the handler
+// returns: normally if it returns nil, with the code of a returned
+// *CloseError, or as an internal error otherwise.
+type WebSocketHandler[Req, In, Out any] func(c *WebSocketConn[In, Out], r *Request[Req]) error
+
+// WebSocket close codes (RFC 6455 section 7.4.1).
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// CloseError is returned by WebSocketConn.Receive once the connection is
+// closed, with the code and reason sent by the client. Handlers may return a
+// CloseError to close the connection with its code and reason.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+}
+
+// maxMessageSize is the size of the largest message accepted from clients.
+const maxMessageSize = 1 << 20
+
+// WebSocketConn is a WebSocket connection exchanging JSON messages. Messages
+// of type []byte are sent and received as binary messages instead. All methods
+// are safe for concurrent use. Once the handler returns, the connection is
+// closed and calls blocked in other goroutines, such as a Receive loop, fail.
+type WebSocketConn[In, Out any] struct {
+	conn *websocket.Conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// WebSocket adds a GET route for path upgrading connections to WebSocket and
+// serving them with handler. The route goes through the middlewares of r, so
+// authentication and the like apply to the handshake. Handshakes from
+// origins other than the host are rejected unless allowed with
+// SetWebSocketOrigins.
+//
+// The operation is documented with the parameters of Req, responding with 101
+// Switching Protocols, with the schemas of the messages in its x-websocket
+// extension:
+//
+//	x-websocket:
+//	  receive: {schema of In}
+//	  send: {schema of Out}
+func WebSocket[Req, In, Out any](r *Router, path string, handler WebSocketHandler[Req, In, Out], props ...HandlerProperty) {
+	h, signature := handler.wrap(r, props)
+	r.mux.Method(http.MethodGet, path, h)
+	r.signatures.add(path, http.MethodGet, signature)
+}
+
+// SetWebSocketOrigins allows WebSocket handshakes of r from origins, such as
+// "https://app.example.com", in addition to those from the host of the
+// request. "*" allows every origin. Sub-routers inherit the origins of their
+// parent unless they set their own.
+func SetWebSocketOrigins(r *Router, origins ...string) {
+	r.webSocketOrigins = origins
+}
+
+// allowedOrigins returns the closest allowed WebSocket origins starting at r.
+func (r *Router) allowedOrigins() []string {
+	for ; r != nil; r = r.parent {
+		if r.webSocketOrigins != nil {
+			return r.webSocketOrigins
+		}
+	}
+	return nil
+}
+
+func (h WebSocketHandler[Req, In, Out]) wrap(router *Router, props []HandlerProperty) (http.HandlerFunc, *handlerSignature) {
+	parse := requestParser[Req](router)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var c *WebSocketConn[In, Out]
+		defer func() {
+			if c != nil {
+				c.conn.CloseNow()
+			}
+		}()
+		defer recoverPanic(func(err error) {
+			if c == nil {
+				router.handleError(w, r, err)
+				return
+			}
+			c.finish(err)
+		})
+
+		req, err := parse(r)
+		if err != nil {
+			router.handleError(w, r, err)
+			return
+		}
+
+		c, err = upgrade[In, Out](w, r, router.allowedOrigins())
+		if err != nil {
+			router.handleError(w, r, err)
+			return
+		}
+		if c == nil {
+			return
+		}
+
+		c.finish(h(c, req))
+	}
+
+	return handler, &handlerSignature{
+		router:    router,
+		reqType:   reflect.TypeFor[Req](),
+		resType:   reflect.TypeFor[Out](),
+		webSocket: true,
+		received:  reflect.TypeFor[In](),
+		props:     props,
+	}
+}
+
+// upgrade performs the server side of the opening handshake. Errors are
+// returned for rejected handshakes, which are responded to with the error
+// handler; if the handshake fails once accepted, upgrade returns nil.
+func upgrade[In, Out any](w http.ResponseWriter, r *http.Request, origins []string) (*WebSocketConn[In, Out], error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		return nil, ErrUpgradeRequired
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, Errorf(ErrBadRequest, "unsupported WebSocket version %q", r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, Errorf(ErrBadRequest, "invalid Sec-WebSocket-Key %q", key)
+	}
+	if !originAllowed(r, origins) {
+		return nil, Errorf(ErrForbidden, "WebSocket origin %q not allowed", r.Header.Get("Origin"))
+	}
+
+	// The origin was checked above; Accept responds to its own failures
+	conn, err := websocket.Accept(deadlineHijacker{w}, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, nil
+	}
+	conn.SetReadLimit(maxMessageSize)
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	return &WebSocketConn[In, Out]{conn: conn, ctx: ctx, cancel: cancel}, nil
+}
+
+// deadlineHijacker clears the deadlines of hijacked connections, which the
+// server no longer tracks.
+type deadlineHijacker struct {
+	http.ResponseWriter
+}
+
+func (w deadlineHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		conn.SetDeadline(time.Time{})
+	}
+	return conn, rw, err
+}
+
+// headerContains reports whether the comma-separated values of the header
+// name contain token, ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	return slices.ContainsFunc(splitList(h.Values(name)), func(v string) bool {
+		return strings.EqualFold(v, token)
+	})
+}
+
+// originAllowed reports whether the Origin of the handshake r is its host or
+// one of origins. Requests without an Origin, which are not sent by
+// browsers, are allowed.
+func originAllowed(r *http.Request, origins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(origins, "*") || slices.Contains(origins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Context returns the context of the connection, done when the handler
+// returns. It carries the values of the handshake request.
+func (c *WebSocketConn[In, Out]) Context() context.Context {
+	return c.ctx
+}
+
+// Send sends v as a message.
+func (c *WebSocketConn[In, Out]) Send(v Out) error {
+	if data, ok := any(v).([]byte); ok {
+		return c.conn.Write(c.ctx, websocket.MessageBinary, data)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.conn.Write(c.ctx, websocket.MessageText, data)
+}
+
+// Receive reads the next message. Pings are answered while waiting for it.
+// Once the client closes the connection, Receive returns a *CloseError.
+func (c *WebSocketConn[In, Out]) Receive() (In, error) {
+	var v In
+	typ, data, err := c.conn.Read(c.ctx)
+	if err != nil {
+		var closeErr websocket.CloseError
+		if errors.As(err, &closeErr) {
+			return v, &CloseError{Code: int(closeErr.Code), Reason: closeErr.Reason}
+		}
+		return v, err
+	}
+
+	if typ == websocket.MessageText && !utf8.Valid(data) {
+		err := &CloseError{Code: CloseInvalidPayload, Reason: "invalid UTF-8"}
+		c.Close(err.Code, err.Reason)
+		return v, err
+	}
+	if p, ok := any(&v).(*[]byte); ok {
+		*p = data
+		return v, nil
+	}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// Ping sends a ping and waits for the pong of the client, which is read while
+// Receive is called.
+func (c *WebSocketConn[In, Out]) Ping() error {
+	return c.conn.Ping(c.ctx)
+}
+
+// KeepAlive sends a ping every interval until the connection closes, and
+// closes it if the client doesn't answer within the interval. As pongs are
+// read by Receive, only handlers receiving messages may use it.
+func (c *WebSocketConn[In, Out]) KeepAlive(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(c.ctx, interval)
+				err := c.conn.Ping(ctx)
+				cancel()
+				if err != nil {
+					c.conn.CloseNow()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close performs the close handshake with code and reason, waiting a few
+// seconds at most for the client to answer. Later calls have no effect.
+func (c *WebSocketConn[In, Out]) Close(code int, reason string) error {
+	if len(reason) > 123 {
+		reason = reason[:123]
+		for !utf8.ValidString(reason) {
+			reason = reason[:len(reason)-1]
+		}
+	}
+	return c.conn.Close(websocket.StatusCode(code), reason)
+}
+
+// finish closes the connection after the handler returned err.
+func (c *WebSocketConn[In, Out]) finish(err error) {
+	// Cancel afterwards, as canceling reads drops the connection
+	defer c.cancel()
+
+	var closeErr *CloseError
+	switch {
+	case errors.As(err, &closeErr):
+		c.Close(closeErr.Code, closeErr.Reason)
+	case err == nil:
+		c.Close(CloseNormal, "")
+	default:
+		slog.Error("Error in WebSocket handler", "err", err.Error())
+		c.Close(CloseInternalError, "")
+	}
+}
+
+// webSocketMessages documents the messages of a WebSocket operation in its
+// x-websocket extension.
+type webSocketMessages struct {
+	Receive spec.Schema `json:"receive"`
+	Send    spec.Schema `json:"send"`
+}
+
+// describeWebSocket documents the handshake and messages of op.
+func describeWebSocket(op *spec.Operation, sig *handlerSignature, structMap *structMap) {
+	op.Parameters = append(op.Parameters, createParameters(sig.reqType)...)
+	op.RespondsWith(http.StatusSwitchingProtocols, spec.NewResponse().WithDescription("Switching Protocols to WebSocket"))
+	op.AddExtension("x-websocket", webSocketMessages{
+		Receive: getType(sig.received, structMap),
+		Send:    getType(sig.resType, structMap),
+	})
+}
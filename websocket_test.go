@@ -0,0 +1,266 @@
+package pf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+type ChatMessage struct {
+	Text string `json:"text"`
+}
+
+type PanickingJoin struct{}
+
+func (PanickingJoin) Validate() error {
+	panic("boom")
+}
+
+type ChatJoin struct {
+	Room string `path:"room"`
+	Name string `query:"name" validate:"required"`
+}
+
+// wsClient is a minimal WebSocket client for tests.
+type wsClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, url string, header http.Header) (*wsClient, *http.Response) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	conn, err := net.Dial("tcp", req.URL.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wsClient{conn: conn, r: r}, res
+}
+
+func (c *wsClient) write(opcode byte, payload []byte) {
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload)), 1, 2, 3, 4}
+	for i, b := range payload {
+		frame = append(frame, b^frame[2+i%4])
+	}
+	c.conn.Write(frame)
+}
+
+func (c *wsClient) read() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := int(header[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	_, err := io.ReadFull(c.r, payload)
+	return header[0] & 0x0f, payload, err
+}
+
+func TestWebSocket(t *testing.T) {
+	received := make(chan error, 1)
+	r := NewRouter()
+	Use(r, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				HandleError(w, ErrUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	WebSocket(r, "/chat/{room}", func(c *WebSocketConn[ChatMessage, ChatMessage], r *Request[ChatJoin]) error {
+		for {
+			msg, err := c.Receive()
+			if err != nil {
+				received <- err
+				return err
+			}
+			if msg.Text == "fail" {
+				return errors.New("failed")
+			}
+			if err := c.Send(ChatMessage{Text: r.Body.Room + " " + r.Body.Name + ": " + strings.ToUpper(msg.Text)}); err != nil {
+				return err
+			}
+		}
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	auth := http.Header{"Authorization": {"Bearer x"}}
+	c, res := dialWebSocket(t, srv.URL+"/chat/lobby?name=ann", auth)
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake = %d %v", res.StatusCode, res.Header)
+	}
+
+	c.write(opPing, []byte("hi"))
+	if op, payload, _ := c.read(); op != opPong || string(payload) != "hi" {
+		t.Errorf("ping answered with %x %q", op, payload)
+	}
+
+	// A fragmented message
+	c.conn.Write([]byte{opText, 0x80 | 6, 0, 0, 0, 0, '{', '"', 't', 'e', 'x', 't'})
+	c.write(opContinuation, []byte(`":"hello"}`))
+	if op, payload, _ := c.read(); op != opText || string(payload) != `{"text":"lobby ann: HELLO"}` {
+		t.Errorf("message = %x %q", op, payload)
+	}
+
+	c.write(opClose, append(binary.BigEndian.AppendUint16(nil, 4000), "bye"...))
+	if op, payload, _ := c.read(); op != opClose || binary.BigEndian.Uint16(payload) != 4000 {
+		t.Errorf("close answered with %x %q", op, payload)
+	}
+	var closeErr *CloseError
+	if err := <-received; !errors.As(err, &closeErr) || closeErr.Code != 4000 || closeErr.Reason != "bye" {
+		t.Errorf("Receive error = %v", err)
+	}
+
+	c, _ = dialWebSocket(t, srv.URL+"/chat/lobby?name=ann", auth)
+	c.write(opText, []byte(`{"text":"fail"}`))
+	if op, payload, _ := c.read(); op != opClose || binary.BigEndian.Uint16(payload) != CloseInternalError {
+		t.Errorf("handler error closed with %x %q", op, payload)
+	}
+
+	for _, test := range []struct {
+		header http.Header
+		status int
+	}{
+		{http.Header{}, http.StatusUnauthorized},
+		{http.Header{"Authorization": {"x"}, "Origin": {"https://evil.example"}}, http.StatusForbidden},
+		{http.Header{"Authorization": {"x"}, "Sec-Websocket-Version": {"8"}}, http.StatusBadRequest},
+	} {
+		if _, res := dialWebSocket(t, srv.URL+"/chat/lobby?name=ann", test.header); res.StatusCode != test.status {
+			t.Errorf("%v: status = %d, want %d", test.header, res.StatusCode, test.status)
+		}
+	}
+
+	if _, res := dialWebSocket(t, srv.URL+"/chat/lobby", auth); res.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("missing name: status = %d", res.StatusCode)
+	}
+
+	// Invalid close frames are answered with a protocol error
+	for _, payload := range [][]byte{
+		{0x03},
+		binary.BigEndian.AppendUint16(nil, CloseNoStatus),
+		binary.BigEndian.AppendUint16(nil, 1015),
+		binary.BigEndian.AppendUint16(nil, 5000),
+	} {
+		c, _ := dialWebSocket(t, srv.URL+"/chat/lobby?name=ann", auth)
+		c.write(opClose, payload)
+		if op, reply, _ := c.read(); op != opClose || binary.BigEndian.Uint16(reply) != CloseProtocolError {
+			t.Errorf("close %x answered with %x %q", payload, op, reply)
+		}
+		<-received
+	}
+
+	res, err := http.Get(srv.URL + "/chat/lobby?name=ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("plain GET status = %d", res.StatusCode)
+	}
+
+	op := must(generateSpec(r, new(SwaggerInfo), nil)).Paths.Paths["/chat/{room}"].Get
+	ws, ok := op.Extensions["x-websocket"].(webSocketMessages)
+	if _, switching := op.Responses.StatusCodeResponses[101]; !ok || !switching || ws.Receive.Ref.String() != "#/definitions/ChatMessage" || len(op.Parameters) != 2 {
+		t.Errorf("operation = %+v", op)
+	}
+
+	doc := must(generateOpenAPI(r, new(SwaggerInfo), nil))
+	ws31 := doc.Paths["/chat/{room}"]["get"].Extensions["x-websocket"].(map[string]jsonSchema)
+	if ws31["send"]["$ref"] != "#/components/schemas/ChatMessage" {
+		t.Errorf("x-websocket = %v", ws31)
+	}
+}
+
+func TestWebSocketHandshakePanic(t *testing.T) {
+	var handled error
+	r := NewRouter()
+	SetErrorHandler(r, func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		HandleError(w, err)
+	})
+	WebSocket(r, "/", func(c *WebSocketConn[ChatMessage, ChatMessage], r *Request[PanickingJoin]) error {
+		return nil
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	if _, res := dialWebSocket(t, srv.URL, nil); res.StatusCode != http.StatusInternalServerError || handled == nil || !strings.HasPrefix(handled.Error(), "panic: boom") {
+		t.Errorf("status = %d, handled %v", res.StatusCode, handled)
+	}
+}
+
+func TestWebSocketReceiveLoop(t *testing.T) {
+	r := NewRouter()
+	WebSocket(r, "/", func(c *WebSocketConn[ChatMessage, ChatMessage], r *Request[struct{}]) error {
+		// Receive in another goroutine, still blocked when the handler returns
+		messages := make(chan ChatMessage)
+		go func() {
+			defer close(messages)
+			for {
+				msg, err := c.Receive()
+				if err != nil {
+					return
+				}
+				messages <- msg
+			}
+		}()
+		c.KeepAlive(time.Hour)
+		return c.Send(<-messages)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	c, _ := dialWebSocket(t, srv.URL, nil)
+	c.write(opText, []byte(`{"text":"hello"}`))
+	if op, payload, _ := c.read(); op != opText || string(payload) != `{"text":"hello"}` {
+		t.Errorf("message = %x %q", op, payload)
+	}
+	if op, payload, _ := c.read(); op != opClose || binary.BigEndian.Uint16(payload) != CloseNormal {
+		t.Errorf("closed with %x %q", op, payload)
+	}
+	c.write(opClose, binary.BigEndian.AppendUint16(nil, CloseNormal))
+	if _, _, err := c.read(); err == nil {
+		t.Error("connection still open after the close handshake")
+	}
+}
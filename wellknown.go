@@ -0,0 +1,128 @@
+package pf
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+var (
+	knownSchemasMu sync.RWMutex
+	knownSchemas   = map[reflect.Type]spec.Schema{
+		reflect.TypeFor[time.Time]():       *spec.DateTimeProperty(),
+		reflect.TypeFor[time.Duration]():   *spec.Int64Property().WithDescription("Duration in nanoseconds"),
+		reflect.TypeFor[net.IP]():          *spec.StrFmtProperty("ip"),
+		reflect.TypeFor[big.Int]():         {SchemaProps: spec.SchemaProps{Type: []string{"integer"}}},
+		reflect.TypeFor[json.Number]():     {SchemaProps: spec.SchemaProps{Type: []string{"number"}}},
+		reflect.TypeFor[json.RawMessage](): {},
+	}
+	oneOfVariants = map[reflect.Type][]reflect.Type{}
+)
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// RegisterSchema makes the specs document values of typ with schema. It
+// overrides the schemas of the well-known types time.Time, time.Duration,
+// net.IP, big.Int, json.Number and json.RawMessage, and the schema generated
+// from typ. Types encoded as JSON objects, like url.URL and the sql.Null
+// types, are documented by their fields unless registered. Structs embedding
+// a sql.Null type as their only field and implementing json.Marshaler, usually
+// to encode invalid values as null, are documented as the nullable value.
+func RegisterSchema(typ reflect.Type, schema spec.Schema) {
+	knownSchemasMu.Lock()
+	defer knownSchemasMu.Unlock()
+	knownSchemas[typ] = schema
+}
+
+// RegisterOneOf makes the specs document values of the interface type typ as
+// one of the types of variants, with a oneOf schema in OpenAPI 3.1. Swagger
+// 2.0 specs document them as free-form, listing the variants in x-oneof.
+//
+//	pf.RegisterOneOf(reflect.TypeFor[Payment](), reflect.TypeFor[Card](), reflect.TypeFor[Transfer]())
+func RegisterOneOf(typ reflect.Type, variants ...reflect.Type) {
+	knownSchemasMu.Lock()
+	defer knownSchemasMu.Unlock()
+	oneOfVariants[typ] = variants
+}
+
+// oneOfSchema returns the schema of types registered with RegisterOneOf.
+func oneOfSchema(typ reflect.Type, structMap *structMap) (spec.Schema, bool) {
+	knownSchemasMu.RLock()
+	variants, ok := oneOfVariants[typ]
+	knownSchemasMu.RUnlock()
+	if !ok {
+		return spec.Schema{}, false
+	}
+
+	var schema spec.Schema
+	for _, variant := range variants {
+		schema.OneOf = append(schema.OneOf, getType(variant, structMap))
+	}
+	return schema, true
+}
+
+// knownSchema returns the schema of types whose JSON encoding doesn't follow
+// from their kind: registered types, json.Marshaler implementations
+// (free-form) and encoding.TextMarshaler implementations (strings).
+func knownSchema(typ reflect.Type) (spec.Schema, bool) {
+	knownSchemasMu.RLock()
+	schema, ok := knownSchemas[typ]
+	knownSchemasMu.RUnlock()
+	if ok {
+		return copySchema(schema), true
+	}
+
+	// encoding/json prefers json.Marshaler to encoding.TextMarshaler
+	ptr := reflect.PointerTo(typ)
+	switch {
+	case ptr.Implements(jsonMarshalerType):
+		// Wrapped sql.Null types are documented by getType
+		_, isNull := sqlNullValue(typ)
+		return spec.Schema{}, !isNull
+	case ptr.Implements(textMarshalerType):
+		return *spec.StringProperty(), true
+	}
+
+	return spec.Schema{}, false
+}
+
+// sqlNullValue returns the type of the value of typ, a sql.Null type such as
+// sql.NullString or a struct embedding one as its only field, and whether typ
+// is one.
+func sqlNullValue(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+	if typ.NumField() == 1 && typ.Field(0).Anonymous {
+		typ = typ.Field(0).Type
+	}
+	if typ.Kind() != reflect.Struct || typ.PkgPath() != "database/sql" || !strings.HasPrefix(typ.Name(), "Null") ||
+		typ.NumField() != 2 || typ.Field(1).Name != "Valid" {
+		return nil, false
+	}
+	return typ.Field(0).Type, true
+}
+
+// copySchema returns a deep copy of schema, so that registered schemas can't
+// be modified through the specs.
+func copySchema(schema spec.Schema) spec.Schema {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return schema
+	}
+	var out spec.Schema
+	if err := json.Unmarshal(data, &out); err != nil {
+		return schema
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package pf
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// restoreSchemas restores the schemas registered with RegisterSchema and
+// RegisterOneOf once t completes.
+func restoreSchemas(t *testing.T) {
+	knownSchemasMu.RLock()
+	schemas, variants := maps.Clone(knownSchemas), maps.Clone(oneOfVariants)
+	knownSchemasMu.RUnlock()
+
+	t.Cleanup(func() {
+		knownSchemasMu.Lock()
+		knownSchemas, oneOfVariants = schemas, variants
+		knownSchemasMu.Unlock()
+	})
+}
+
+type Record struct {
+	Created  time.Time       `json:"created"`
+	Payload  []byte          `json:"payload"`
+	Raw      json.RawMessage `json:"raw"`
+	Extra    any             `json:"extra"`
+	Address  net.IP          `json:"address"`
+	Nickname sql.NullString  `json:"nickname"`
+	Key      Key             `json:"key"`
+	Name     NullName        `json:"name"`
+	Count    NullCount       `json:"count"`
+}
+
+// NullName is encoded as a nullable string.
+type NullName struct{ sql.NullString }
+
+func (n NullName) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.String)
+}
+
+// NullCount is encoded as a nullable integer.
+type NullCount struct{ sql.Null[int64] }
+
+func (n NullCount) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+// Key is encoded as text.
+type Key [4]byte
+
+func (k Key) MarshalText() ([]byte, error) { return []byte(hex.EncodeToString(k[:])), nil }
+
+type Money int64
+
+func TestWellKnownSchemas(t *testing.T) {
+	restoreSchemas(t)
+	RegisterSchema(reflect.TypeFor[Money](), *spec.StrFmtProperty("decimal"))
+
+	r := NewRouter()
+	Get(r, "/records", func(w ResponseWriter[Record], r *Request[struct{}]) error {
+		return nil
+	})
+	Get(r, "/balance", func(w ResponseWriter[Money], r *Request[struct{}]) error {
+		return nil
+	})
+
+	s := must(generateSpec(r, new(SwaggerInfo), nil))
+	props := s.Definitions["Record"].Properties
+	tests := map[string]string{
+		"created": "string/date-time",
+		"payload": "string/byte",
+		"raw":     "/",
+		"extra":   "/",
+		"address": "string/ip",
+		"key":     "string/",
+		"name":    "string/",
+		"count":   "integer/",
+	}
+	for name, want := range tests {
+		p := props[name]
+		if got := strings.Join(p.Type, ",") + "/" + p.Format; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	// sql.Null types wrapped with a JSON marshaler are nullable scalars
+	for _, name := range []string{"name", "count"} {
+		if props[name].Extensions["x-nullable"] != true {
+			t.Errorf("%s isn't nullable: %+v", name, props[name])
+		}
+	}
+	// sql.NullString is encoded as an object by encoding/json
+	if p := props["nickname"]; p.Ref.String() != "#/definitions/NullString" {
+		t.Errorf("nickname = %+v", p)
+	}
+	if fields := s.Definitions["NullString"].Properties; len(fields) != 2 || fields["String"].Type[0] != "string" || fields["Valid"].Type[0] != "boolean" {
+		t.Errorf("NullString = %+v", fields)
+	}
+
+	balance := s.Paths.Paths["/balance"].Get.Responses.StatusCodeResponses[200].Schema
+	if balance.Format != "decimal" {
+		t.Errorf("balance = %+v", balance.SchemaProps)
+	}
+}